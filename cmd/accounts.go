@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"x-qdo/jiraclick/pkg/admin"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+)
+
+type accountsCmd struct {
+	ctx            context.Context
+	db             contract.Storage
+	accounts       *admin.AccountsService
+	resource       string
+	channel        string
+	props          string
+	propsFile      string
+	all            bool
+	output         string
+	clickupStatus  string
+	jiraStatus     string
+	jiraTransition string
+}
+
+func NewAccountsCmd(
+	ctx context.Context,
+	cancelF context.CancelFunc,
+	load DependenciesLoader,
+) *cobra.Command {
+	c := &accountsCmd{
+		ctx: ctx,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "accounts",
+		Short: "Manages tenant accounts",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			deps, err := load()
+			if err != nil {
+				return err
+			}
+			c.db = deps.DB
+			c.accounts = admin.NewAccountsService(deps.DB)
+
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			cancelF()
+		},
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "Lists accounts with masked secrets",
+		RunE:  c.list,
+	}
+	list.Flags().StringVar(&c.resource, "resource", "", "clickup or jira, all resources by default")
+	list.Flags().BoolVar(&c.all, "all", false, "include disabled accounts")
+	list.Flags().StringVarP(&c.output, "output", "o", "table", "output format: table or json")
+
+	add := &cobra.Command{
+		Use:   "add",
+		Short: "Adds an account",
+		RunE:  c.add,
+	}
+	c.accountFlags(add)
+	c.propsFlags(add)
+
+	update := &cobra.Command{
+		Use:   "update",
+		Short: "Merges props into an account",
+		RunE:  c.update,
+	}
+	c.accountFlags(update)
+	c.propsFlags(update)
+
+	disable := &cobra.Command{
+		Use:   "disable",
+		Short: "Disables an account",
+		RunE:  c.disable,
+	}
+	c.accountFlags(disable)
+
+	rotateKey := &cobra.Command{
+		Use:   "rotate-key",
+		Short: "Re-encrypts secrets of all accounts with the active key",
+		Long: `Re-encrypts secret props of all accounts with the key set by encryption.active.
+Plain secrets and secrets sealed without the account binding are encrypted as well. Keys of previous
+rotations must stay configured till the command succeeds.`,
+		RunE: c.rotateKey,
+	}
+
+	cmd.AddCommand(list, add, update, disable, rotateKey, c.statusMappingCmd())
+
+	return cmd
+}
+
+func (c *accountsCmd) statusMappingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status-mapping",
+		Short: "Manages ClickUp to Jira status mappings of a tenant",
+		Long: `Manages ClickUp to Jira status mappings of a tenant. Mappings are used only when the sync.status
+prop of the tenant's Jira account is set to both, to_jira or to_clickup; statuses without a mapping are
+not synced.`,
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "Lists status mappings",
+		RunE:  c.listStatusMappings,
+	}
+	c.channelFlag(list)
+
+	set := &cobra.Command{
+		Use:   "set",
+		Short: "Maps a ClickUp status to a Jira status, replacing its previous mapping",
+		RunE:  c.setStatusMapping,
+	}
+	c.channelFlag(set)
+	set.Flags().StringVar(&c.clickupStatus, "clickup-status", "", "ClickUp status")
+	set.Flags().StringVar(&c.jiraStatus, "jira-status", "", "Jira status")
+	set.Flags().StringVar(&c.jiraTransition, "jira-transition", "",
+		"name or ID of the Jira transition, any transition leading to the Jira status by default")
+	_ = set.MarkFlagRequired("clickup-status")
+	_ = set.MarkFlagRequired("jira-status")
+
+	remove := &cobra.Command{
+		Use:   "delete",
+		Short: "Deletes the mapping of a ClickUp status",
+		RunE:  c.deleteStatusMapping,
+	}
+	c.channelFlag(remove)
+	remove.Flags().StringVar(&c.clickupStatus, "clickup-status", "", "ClickUp status")
+	_ = remove.MarkFlagRequired("clickup-status")
+
+	cmd.AddCommand(list, set, remove)
+
+	return cmd
+}
+
+func (c *accountsCmd) accountFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&c.resource, "resource", "", "clickup or jira")
+	cmd.Flags().StringVar(&c.channel, "channel", "", "Slack channel of the account")
+	_ = cmd.MarkFlagRequired("resource")
+	_ = cmd.MarkFlagRequired("channel")
+}
+
+func (c *accountsCmd) channelFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&c.channel, "channel", "", "Slack channel of the tenant")
+	_ = cmd.MarkFlagRequired("channel")
+}
+
+func (c *accountsCmd) propsFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&c.props, "props", "", "account props as a JSON object")
+	cmd.Flags().StringVar(&c.propsFile, "props-file", "", "file with account props as a JSON object")
+}
+
+func (c *accountsCmd) list(cmd *cobra.Command, args []string) error {
+	accounts, err := c.accounts.List(c.ctx, c.resource, c.all)
+	if err != nil {
+		return err
+	}
+
+	if c.output == "json" {
+		return printJSON(accounts)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tRESOURCE\tCHANNEL\tSTATUS\tPROPS")
+	for _, account := range accounts {
+		status := "active"
+		if account.Disabled() {
+			status = "disabled"
+		}
+		props, _ := json.Marshal(account.Props)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", account.ID, account.Resource, account.SlackChannel, status, props)
+	}
+
+	return w.Flush()
+}
+
+func (c *accountsCmd) add(cmd *cobra.Command, args []string) error {
+	props, err := c.readProps()
+	if err != nil {
+		return err
+	}
+
+	account, err := c.accounts.Add(c.ctx, admin.AccountRequest{
+		Resource:     c.resource,
+		SlackChannel: c.channel,
+		Props:        props,
+	})
+	if err != nil {
+		return err
+	}
+
+	return printJSON(account)
+}
+
+func (c *accountsCmd) update(cmd *cobra.Command, args []string) error {
+	props, err := c.readProps()
+	if err != nil {
+		return err
+	}
+
+	account, err := c.accounts.Update(c.ctx, c.resource, c.channel, props)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(account)
+}
+
+func (c *accountsCmd) disable(cmd *cobra.Command, args []string) error {
+	if err := c.accounts.Disable(c.ctx, c.resource, c.channel); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s account for %s is disabled\n", c.resource, c.channel)
+
+	return nil
+}
+
+func (c *accountsCmd) rotateKey(cmd *cobra.Command, args []string) error {
+	updated, err := c.db.ReencryptAccounts(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d accounts are re-encrypted\n", updated)
+
+	return nil
+}
+
+func (c *accountsCmd) listStatusMappings(cmd *cobra.Command, args []string) error {
+	mappings, err := c.db.GetStatusMappings(c.ctx, c.channel)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLICKUP STATUS\tJIRA STATUS\tJIRA TRANSITION")
+	for _, mapping := range mappings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", mapping.ClickUpStatus, mapping.JiraStatus, mapping.JiraTransition)
+	}
+
+	return w.Flush()
+}
+
+func (c *accountsCmd) setStatusMapping(cmd *cobra.Command, args []string) error {
+	account, err := c.accounts.Get(c.ctx, model.JiraResource, c.channel)
+	if err != nil {
+		return err
+	}
+
+	mapping := &model.StatusMapping{
+		SlackChannel:   account.SlackChannel,
+		ClickUpStatus:  c.clickupStatus,
+		JiraStatus:     c.jiraStatus,
+		JiraTransition: c.jiraTransition,
+	}
+	if err = c.db.UpsertStatusMapping(c.ctx, mapping); err != nil {
+		return err
+	}
+
+	fmt.Printf("%q is mapped to %q for %s\n", mapping.ClickUpStatus, mapping.JiraStatus, mapping.SlackChannel)
+	sync, _ := account.Props["sync"].(map[string]interface{})
+	direction, _ := sync[string(model.SyncFieldStatus)].(string)
+	settings := model.SyncSettings{model.SyncFieldStatus: model.SyncDirection(direction)}
+	if !settings.ToJira(model.SyncFieldStatus) && !settings.ToClickUp(model.SyncFieldStatus) {
+		fmt.Fprintf(os.Stderr, "statuses are not synced for %s till sync.status of its jira account is set\n", account.SlackChannel)
+	}
+
+	return nil
+}
+
+func (c *accountsCmd) deleteStatusMapping(cmd *cobra.Command, args []string) error {
+	deleted, err := c.db.DeleteStatusMapping(c.ctx, c.channel, c.clickupStatus)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%q has no mapping for %s", c.clickupStatus, c.channel)
+	}
+
+	fmt.Printf("mapping of %q is deleted for %s\n", c.clickupStatus, c.channel)
+
+	return nil
+}
+
+func (c *accountsCmd) readProps() (map[string]interface{}, error) {
+	var props map[string]interface{}
+
+	raw := []byte(c.props)
+	if c.propsFile != "" {
+		b, err := ioutil.ReadFile(c.propsFile)
+		if err != nil {
+			return nil, err
+		}
+		raw = b
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("--props or --props-file is required")
+	}
+
+	if err := json.Unmarshal(raw, &props); err != nil {
+		return nil, fmt.Errorf("props must be a JSON object: %w", err)
+	}
+
+	return props, nil
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
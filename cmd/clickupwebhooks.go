@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+)
+
+type clickUpWebhooksCmd struct {
+	ctx      context.Context
+	clickup  *clickup.ConnectorPool
+	db       contract.Storage
+	tenant   string
+	id       string
+	endpoint string
+	events   []string
+	status   string
+	fix      bool
+}
+
+func NewClickUpWebhooksCmd(
+	ctx context.Context,
+	cancelF context.CancelFunc,
+	load DependenciesLoader,
+) *cobra.Command {
+	c := &clickUpWebhooksCmd{
+		ctx: ctx,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "clickup-webhooks",
+		Short: "Manages ClickUp webhooks",
+		Long:  `Lists, creates, updates, deletes and checks health of ClickUp webhooks of the accounts.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			deps, err := load()
+			if err != nil {
+				return err
+			}
+			c.clickup, c.db = deps.ClickUp, deps.DB
+
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			cancelF()
+		},
+	}
+	cmd.PersistentFlags().StringVar(&c.tenant, "tenant", "", "Slack channel of the account")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "Lists webhooks of the account list",
+		RunE:  c.list,
+	}
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Creates a webhook and stores its secret in the account",
+		RunE:  c.create,
+	}
+	create.Flags().StringVar(&c.endpoint, "endpoint", "", "URL of the webhooks/clickup/<tenant> route")
+	create.Flags().StringSliceVar(&c.events, "events", defaultWebhookEvents(), "events to subscribe")
+	_ = create.MarkFlagRequired("endpoint")
+
+	update := &cobra.Command{
+		Use:   "update",
+		Short: "Updates a webhook",
+		RunE:  c.update,
+	}
+	update.Flags().StringVar(&c.id, "id", "", "webhook ID")
+	update.Flags().StringVar(&c.endpoint, "endpoint", "", "URL of the webhooks/clickup/<tenant> route")
+	update.Flags().StringSliceVar(&c.events, "events", nil, "events to subscribe")
+	update.Flags().StringVar(&c.status, "status", "", "webhook status, `active` re-enables a suspended webhook")
+	_ = update.MarkFlagRequired("id")
+
+	del := &cobra.Command{
+		Use:   "delete",
+		Short: "Deletes a webhook",
+		RunE:  c.delete,
+	}
+	del.Flags().StringVar(&c.id, "id", "", "webhook ID")
+	_ = del.MarkFlagRequired("id")
+
+	health := &cobra.Command{
+		Use:   "health",
+		Short: "Checks that webhooks are active",
+		Long:  `Checks that webhooks are active and exits with an error otherwise; --fix re-activates failing webhooks.`,
+		RunE:  c.health,
+	}
+	health.Flags().BoolVar(&c.fix, "fix", false, "re-activate failing webhooks")
+
+	cmd.AddCommand(list, create, update, del, health)
+
+	return cmd
+}
+
+func (c *clickUpWebhooksCmd) list(cmd *cobra.Command, args []string) error {
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TENANT\tID\tENDPOINT\tSTATUS\tFAILS\tEVENTS")
+	for _, tenant := range tenants {
+		client, err := c.clickup.GetTenantInstance(tenant)
+		if err != nil {
+			return err
+		}
+		webhooks, err := client.ListWebhooks(c.ctx)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		for _, webhook := range webhooks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+				tenant,
+				webhook.ID,
+				webhook.Endpoint,
+				webhook.Health.Status,
+				webhook.Health.FailCount,
+				strings.Join(webhook.Events, ","),
+			)
+		}
+	}
+
+	return w.Flush()
+}
+
+func (c *clickUpWebhooksCmd) create(cmd *cobra.Command, args []string) error {
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+	if len(tenants) != 1 {
+		return fmt.Errorf("--tenant is required")
+	}
+	tenant := tenants[0]
+
+	client, err := c.clickup.GetTenantInstance(tenant)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := client.CreateWebhook(c.ctx, &clickup.WebhookRequest{
+		Endpoint: c.endpoint,
+		Events:   c.events,
+	})
+	if err != nil {
+		return fmt.Errorf("tenant %s: %w", tenant, err)
+	}
+
+	// the replaced secret stays accepted, since webhooks created before keep signing with it till they're deleted
+	props := map[string]interface{}{
+		"webhooksecret": webhook.Secret,
+	}
+	if account, ok := c.clickup.GetAccounts()[strings.ToLower(tenant)]; ok && account.WebhookSecret != "" {
+		secrets := make([]string, 0, len(account.WebhookSecrets)+1)
+		secrets = append(secrets, account.WebhookSecrets...)
+		secrets = append(secrets, account.WebhookSecret)
+		props["webhooksecrets"] = c.activeSecrets(client, tenant, secrets)
+	}
+
+	err = c.db.UpdateAccountProps(c.ctx, model.ClickUpResource, tenant, props)
+	if err != nil {
+		return fmt.Errorf("tenant %s: webhook %s is created, but its secret is not stored: %w", tenant, webhook.ID, err)
+	}
+
+	fmt.Printf("webhook %s is created for %s, the secret is stored in the account\n", webhook.ID, tenant)
+
+	return nil
+}
+
+func (c *clickUpWebhooksCmd) update(cmd *cobra.Command, args []string) error {
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+	if len(tenants) != 1 {
+		return fmt.Errorf("--tenant is required")
+	}
+
+	client, err := c.clickup.GetTenantInstance(tenants[0])
+	if err != nil {
+		return err
+	}
+
+	webhook, err := client.UpdateWebhook(c.ctx, c.id, &clickup.WebhookRequest{
+		Endpoint: c.endpoint,
+		Events:   c.events,
+		Status:   c.status,
+	})
+	if err != nil {
+		return fmt.Errorf("tenant %s: %w", tenants[0], err)
+	}
+
+	fmt.Printf("webhook %s is updated, status: %s\n", webhook.ID, webhook.Health.Status)
+
+	return nil
+}
+
+func (c *clickUpWebhooksCmd) delete(cmd *cobra.Command, args []string) error {
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+	if len(tenants) != 1 {
+		return fmt.Errorf("--tenant is required")
+	}
+
+	client, err := c.clickup.GetTenantInstance(tenants[0])
+	if err != nil {
+		return err
+	}
+
+	if err = client.DeleteWebhook(c.ctx, c.id); err != nil {
+		return fmt.Errorf("tenant %s: %w", tenants[0], err)
+	}
+
+	fmt.Printf("webhook %s is deleted\n", c.id)
+
+	account, ok := c.clickup.GetAccounts()[strings.ToLower(tenants[0])]
+	if !ok || len(account.WebhookSecrets) == 0 {
+		return nil
+	}
+	props := map[string]interface{}{
+		"webhooksecrets": c.activeSecrets(client, tenants[0], account.WebhookSecrets),
+	}
+	if err = c.db.UpdateAccountProps(c.ctx, model.ClickUpResource, tenants[0], props); err != nil {
+		return fmt.Errorf("tenant %s: secrets of deleted webhooks are not pruned: %w", tenants[0], err)
+	}
+
+	return nil
+}
+
+// activeSecrets drops previous secrets which no webhook of the tenant signs with anymore. All of them are kept
+// when webhooks can't be listed or ClickUp doesn't return their secrets.
+func (c *clickUpWebhooksCmd) activeSecrets(client clickup.ClientInterface, tenant string, secrets []string) []interface{} {
+	keep := func(string) bool { return true }
+
+	webhooks, err := client.ListWebhooks(c.ctx)
+	if err != nil {
+		fmt.Printf("%s: previous webhook secrets are kept, webhooks can't be listed: %s\n", tenant, err.Error())
+	} else {
+		active := make(map[string]bool, len(webhooks))
+		known := true
+		for _, webhook := range webhooks {
+			known = known && webhook.Secret != ""
+			active[webhook.Secret] = true
+		}
+		if known {
+			keep = func(secret string) bool { return active[secret] }
+		}
+	}
+
+	kept := make([]interface{}, 0, len(secrets))
+	for _, secret := range secrets {
+		if secret != "" && keep(secret) {
+			kept = append(kept, secret)
+		}
+	}
+
+	return kept
+}
+
+func (c *clickUpWebhooksCmd) health(cmd *cobra.Command, args []string) error {
+	var failing int
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range tenants {
+		client, err := c.clickup.GetTenantInstance(tenant)
+		if err != nil {
+			return err
+		}
+		webhooks, err := client.ListWebhooks(c.ctx)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		if len(webhooks) == 0 {
+			failing++
+			fmt.Printf("%s: no webhooks\n", tenant)
+			continue
+		}
+
+		for _, webhook := range webhooks {
+			if webhook.Health.Status == clickup.WebhookStatusActive {
+				fmt.Printf("%s: webhook %s is active (fails: %d)\n", tenant, webhook.ID, webhook.Health.FailCount)
+				continue
+			}
+
+			fmt.Printf("%s: webhook %s is %s (fails: %d)\n", tenant, webhook.ID, webhook.Health.Status, webhook.Health.FailCount)
+			if !c.fix {
+				failing++
+				continue
+			}
+			_, err = client.UpdateWebhook(c.ctx, webhook.ID, &clickup.WebhookRequest{
+				Endpoint: webhook.Endpoint,
+				Events:   webhook.Events,
+				Status:   clickup.WebhookStatusActive,
+			})
+			if err != nil {
+				failing++
+				fmt.Printf("%s: webhook %s can't be re-activated: %s\n", tenant, webhook.ID, err.Error())
+				continue
+			}
+			fmt.Printf("%s: webhook %s is re-activated\n", tenant, webhook.ID)
+		}
+	}
+
+	if failing > 0 {
+		return fmt.Errorf("%d webhook checks failed", failing)
+	}
+
+	return nil
+}
+
+// tenants returns the account of --tenant or all ClickUp accounts, as they are named in the database.
+func (c *clickUpWebhooksCmd) tenants() ([]string, error) {
+	accounts, err := c.db.GetClickUpAccounts(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := make([]string, 0, len(accounts))
+	for tenant := range accounts {
+		if c.tenant == "" || strings.EqualFold(tenant, c.tenant) {
+			tenants = append(tenants, tenant)
+		}
+	}
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("ClickUp account for %q is not found", c.tenant)
+	}
+	sort.Strings(tenants)
+
+	return tenants, nil
+}
+
+func defaultWebhookEvents() []string {
+	events := make([]string, 0, len(clickup.TaskEventTypes))
+	for _, event := range clickup.TaskEventTypes {
+		events = append(events, string(event))
+	}
+
+	return events
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"x-qdo/jiraclick/pkg/doctor"
+)
+
+type doctorCmd struct {
+	ctx    context.Context
+	output string
+}
+
+func NewDoctorCmd(ctx context.Context) *cobra.Command {
+	c := &doctorCmd{
+		ctx: ctx,
+	}
+
+	cmd := &cobra.Command{
+		Use:           "doctor",
+		Short:         "Verifies connections and configuration of every account",
+		Long:          "Pings Postgres and RabbitMQ, then checks every ClickUp and Jira account against the APIs. Exits with a non-zero code when a check fails.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          c.run,
+	}
+	cmd.Flags().StringVarP(&c.output, "output", "o", "table", "output format: table or json")
+
+	return cmd
+}
+
+func (c *doctorCmd) run(cmd *cobra.Command, args []string) error {
+	report := doctor.Run(c.ctx)
+
+	if c.output == "json" {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else if err := printReport(report); err != nil {
+		return err
+	}
+
+	if !report.Passed {
+		return fmt.Errorf("%d checks failed", report.Failed())
+	}
+
+	return nil
+}
+
+func printReport(report doctor.Report) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tTENANT\tCHECK\tSTATUS\tDETAIL")
+	for _, check := range report.Checks {
+		status := "pass"
+		if !check.Passed {
+			status = "FAIL"
+		}
+		tenant := check.Tenant
+		if tenant == "" {
+			tenant = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", check.Resource, tenant, check.Name, status, check.Detail)
+	}
+
+	return w.Flush()
+}
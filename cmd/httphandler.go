@@ -1,37 +1,41 @@
 package cmd
 
 import (
-	"github.com/astreter/amqpwrapper/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
-	"x-qdo/jiraclick/pkg/contract"
 
+	"x-qdo/jiraclick/pkg/admin"
 	"x-qdo/jiraclick/pkg/config"
 	"x-qdo/jiraclick/pkg/handler"
-	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/metrics"
 )
 
-func NewHTTPHandlerCmd(
-	cfg *config.Config,
-	logger *logrus.Logger,
-	queue *amqpwrapper.RabbitChannel,
-	clickup *clickup.ConnectorPool,
-	db contract.Storage,
-) *cobra.Command {
+func NewHTTPHandlerCmd(load DependenciesLoader) *cobra.Command {
+	var deps *Dependencies
+
 	return &cobra.Command{
 		Use:   "http-handler",
 		Short: "Runs HTTP handler",
 		Long:  `Runs HTTP server to handle API requests and webhooks`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			deps, err = load()
+			return err
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			var router = gin.New()
+			cfg, logger := deps.Config, deps.Logger
 
 			if cfg.Debug {
 				gin.SetMode(gin.DebugMode)
 			}
 
-			clickUpHandler, err := handler.NewClickUpWebhooksHandler(cfg, logger, queue, clickup, db)
+			clickUpHandler, err := handler.NewClickUpWebhooksHandler(cfg, logger, deps.Queue, deps.ClickUp)
+			if err != nil {
+				panic(err)
+			}
+
+			jiraHandler, err := handler.NewJiraWebhooksHandler(cfg, logger, deps.Queue, deps.Jira)
 			if err != nil {
 				panic(err)
 			}
@@ -46,6 +50,25 @@ func NewHTTPHandlerCmd(
 			router.Use(otelgin.Middleware(config.ServiceName))
 
 			router.POST("webhooks/clickup", clickUpHandler.TaskEvent)
+			router.POST("webhooks/clickup/:tenant", clickUpHandler.TenantTaskEvent)
+			router.POST("webhooks/jira", jiraHandler.TaskEvent)
+			router.POST("webhooks/jira/:tenant", jiraHandler.TenantTaskEvent)
+
+			if cfg.Admin.Token != "" {
+				adminHandler := handler.NewAdminAccountsHandler(cfg, logger, admin.NewAccountsService(deps.DB))
+				accounts := router.Group("admin/accounts", adminHandler.Authorize)
+				accounts.GET("", adminHandler.List)
+				accounts.POST("", adminHandler.Add)
+				accounts.GET(":resource/:channel", adminHandler.Get)
+				accounts.PATCH(":resource/:channel", adminHandler.Update)
+				accounts.DELETE(":resource/:channel", adminHandler.Disable)
+			}
+
+			go func() {
+				if err := metrics.Serve(cfg.Metrics.Port); err != nil {
+					panic(err)
+				}
+			}()
 
 			go func() {
 				if err := router.Run(":" + cfg.HTTPHandler.Port); err != nil {
@@ -3,9 +3,30 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/astreter/amqpwrapper/v2"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/provider/jira"
 )
 
+// Dependencies are connections and account pools shared by the commands.
+type Dependencies struct {
+	Config  *config.Config
+	Logger  *logrus.Logger
+	Queue   *amqpwrapper.RabbitChannel
+	ClickUp *clickup.ConnectorPool
+	Jira    *jira.ConnectorPool
+	DB      contract.Storage
+}
+
+// DependenciesLoader is called by PersistentPreRunE of the commands which need the dependencies, so help and
+// doctor run without connecting to them.
+type DependenciesLoader func() (*Dependencies, error)
+
 func NewRootCmd() *cobra.Command {
 	return &cobra.Command{
 		Use: "jiraclick",
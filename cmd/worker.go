@@ -1,32 +1,48 @@
 package cmd
 
 import (
-	"github.com/astreter/amqpwrapper/v2"
+	"context"
+
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"x-qdo/jiraclick/pkg/consumer"
 	"x-qdo/jiraclick/pkg/contract"
-	"x-qdo/jiraclick/pkg/provider/clickup"
-	"x-qdo/jiraclick/pkg/provider/jira"
+	"x-qdo/jiraclick/pkg/metrics"
+	"x-qdo/jiraclick/pkg/publisher"
 )
 
-func NewWorkerCmd(
-	queue *amqpwrapper.RabbitChannel,
-	clickup *clickup.ConnectorPool,
-	jira *jira.ConnectorPool,
-) *cobra.Command {
+func NewWorkerCmd(ctx context.Context, load DependenciesLoader) *cobra.Command {
+	var deps *Dependencies
+
 	return &cobra.Command{
 		Use:   "worker",
 		Short: "Runs tasks consumer",
 		Long:  `Runs consumer to receive and process tasks from queue.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			deps, err = load()
+			return err
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			var (
 				cons contract.Consumer
 				err  error
 			)
+			cfg, db := deps.Config, deps.DB
 
 			go func() {
-				cons, err = consumer.NewActionsConsumer(jira, queue, clickup)
+				if err := metrics.Serve(cfg.Metrics.Port); err != nil {
+					panic(err)
+				}
+			}()
+
+			go func() {
+				// one misconfigured account mustn't stop processing of the other tenants
+				if err := deps.Jira.Validate(context.Background()); err != nil {
+					logrus.Error(err)
+				}
+
+				cons, err = consumer.NewActionsConsumer(cfg, deps.Jira, deps.Queue, deps.ClickUp, db)
 				if err != nil {
 					panic(err)
 				}
@@ -35,6 +51,10 @@ func NewWorkerCmd(
 				if err != nil {
 					panic(err)
 				}
+
+				go consumer.NewWebhookHistoryCleaner(cfg, db).Run(ctx)
+				go consumer.NewRetentionCleaner(cfg, db).Run(ctx)
+				publisher.NewOutboxRelay(deps.Queue, db).Run(ctx)
 			}()
 		},
 	}
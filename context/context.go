@@ -5,10 +5,11 @@ import (
 	"github.com/astreter/amqpwrapper/v2"
 	"github.com/x-qdo/otelwrapper"
 	"sync"
-	"x-qdo/jiraclick/pkg/contract"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 
 	"x-qdo/jiraclick/cmd"
 	"x-qdo/jiraclick/pkg/config"
@@ -20,18 +21,57 @@ import (
 type Context struct {
 	Ctx       context.Context
 	CancelF   context.CancelFunc
-	Config    config.Config
 	RootCmd   *cobra.Command
 	WaitGroup *sync.WaitGroup
+
+	once    sync.Once
+	deps    *cmd.Dependencies
+	depsErr error
+	loaded  bool
 }
 
 func NewContext() (*Context, error) {
-	var ctx Context
+	ctx := new(Context)
 	ctx.Ctx, ctx.CancelF = context.WithCancel(context.Background())
+	ctx.WaitGroup = new(sync.WaitGroup)
+
+	setCommands(ctx)
+
+	return ctx, nil
+}
+
+func setCommands(ctx *Context) {
+	rootCmd := cmd.NewRootCmd()
+
+	rootCmd.AddCommand(cmd.NewWorkerCmd(ctx.Ctx, ctx.dependencies))
+	rootCmd.AddCommand(cmd.NewHTTPHandlerCmd(ctx.dependencies))
+	rootCmd.AddCommand(cmd.NewClickUpWebhooksCmd(ctx.Ctx, ctx.CancelF, ctx.dependencies))
+	rootCmd.AddCommand(cmd.NewAccountsCmd(ctx.Ctx, ctx.CancelF, ctx.dependencies))
+	// the doctor connects on its own, since it has to report unreachable dependencies
+	rootCmd.AddCommand(cmd.NewDoctorCmd(ctx.Ctx))
 
+	ctx.RootCmd = rootCmd
+}
+
+// Loaded reports whether a command has built the dependencies, whose goroutines have to be waited for on shutdown.
+func (c *Context) Loaded() bool {
+	return c.loaded
+}
+
+// dependencies are built once by the first command which needs them.
+func (c *Context) dependencies() (*cmd.Dependencies, error) {
+	c.once.Do(func() {
+		c.loaded = true
+		c.deps, c.depsErr = c.newDependencies()
+	})
+
+	return c.deps, c.depsErr
+}
+
+func (c *Context) newDependencies() (*cmd.Dependencies, error) {
 	cfg, err := config.NewConfig()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	logger := logrus.New()
@@ -40,74 +80,62 @@ func NewContext() (*Context, error) {
 		logrus.SetLevel(logrus.DebugLevel)
 	}
 
-	ctx.WaitGroup = new(sync.WaitGroup)
-
 	tp, err := otelwrapper.InitTracerProvider(config.ServiceName, "default")
 	if err != nil {
 		return nil, err
 	}
-	go otelwrapper.ShutdownWaiting(tp, ctx.Ctx, ctx.WaitGroup)
+	go otelwrapper.ShutdownWaiting(tp, c.Ctx, c.WaitGroup)
+	// otelwrapper propagates the trace context only, baggage has to cross HTTP and AMQP boundaries as well
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	amqpProvider, err := amqpwrapper.NewRabbitChannel(ctx.Ctx, ctx.WaitGroup, &amqpwrapper.Config{
+	amqpProvider, err := amqpwrapper.NewRabbitChannel(c.Ctx, c.WaitGroup, &amqpwrapper.Config{
 		URL:          cfg.RabbitMQ.URL,
 		Debug:        cfg.Debug,
 		ConfirmSends: true,
 	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	go func() {
 		<-amqpProvider.Cancel()
-		ctx.CancelF()
+		c.CancelF()
 	}()
 
 	db, err := provider.NewPostgres(cfg)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	clickUpAccounts, err := db.GetClickUpAccounts(ctx.Ctx)
+	clickUpAccounts, err := db.GetClickUpAccounts(c.Ctx)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	clickupProvider, err := clickup.NewClickUpConnector(clickUpAccounts)
+	// misconfigured accounts are skipped, the commands to fix them have to run
+	clickupProvider, err := clickup.NewClickUpConnector(c.Ctx, clickUpAccounts)
 	if err != nil {
-		panic(err)
+		logger.Error(err)
 	}
 
-	jiraAccounts, err := db.GetJiraAccounts(ctx.Ctx)
+	jiraAccounts, err := db.GetJiraAccounts(c.Ctx)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	jiraProvider, err := jira.NewJiraConnector(jiraAccounts)
 	if err != nil {
-		panic(err)
+		logger.Error(err)
 	}
 
-	setCommands(&ctx, cfg, amqpProvider, clickupProvider, jiraProvider, logger, db)
+	go provider.NewAccountsReloader(cfg, logger, db, clickupProvider, jiraProvider).Run(c.Ctx)
 
-	return &ctx, nil
-}
-
-func setCommands(
-	ctx *Context,
-	cfg *config.Config,
-	queue *amqpwrapper.RabbitChannel,
-	clickup *clickup.ConnectorPool,
-	jira *jira.ConnectorPool,
-	logger *logrus.Logger,
-	db contract.Storage,
-) {
-	workerCmd := cmd.NewWorkerCmd(queue, clickup, jira)
-	httpHandlerCmd := cmd.NewHTTPHandlerCmd(cfg, logger, queue, clickup, db)
-
-	rootCmd := cmd.NewRootCmd()
-
-	rootCmd.AddCommand(workerCmd)
-	rootCmd.AddCommand(httpHandlerCmd)
-
-	ctx.RootCmd = rootCmd
+	return &cmd.Dependencies{
+		Config:  cfg,
+		Logger:  logger,
+		Queue:   amqpProvider,
+		ClickUp: clickupProvider,
+		Jira:    jiraProvider,
+		DB:      db,
+	}, nil
 }
 
 func (c *Context) Done() <-chan struct{} {
@@ -19,12 +19,20 @@ func main() {
 
 	go waitShutdown(ctx.CancelF)
 
+	exitCode := 0
 	if err := ctx.RootCmd.Execute(); err != nil {
 		fmt.Println(err)
+		exitCode = 1
 		ctx.CancelF()
 	}
-	<-ctx.Done()
-	ctx.WaitGroup.Wait()
+	// help, doctor and other commands without dependencies have nothing running in the background
+	if ctx.Loaded() {
+		<-ctx.Done()
+		ctx.WaitGroup.Wait()
+	}
+
+	// a failed command, e.g. a webhooks health check, must be visible to cron and monitoring
+	os.Exit(exitCode)
 }
 
 func waitShutdown(cancelF context.CancelFunc) {
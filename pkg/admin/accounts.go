@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+)
+
+const maxSlackChannelLength = 10
+
+var (
+	ErrAccountNotFound = errors.New("account is not found")
+	ErrAccountExists   = errors.New("account already exists")
+)
+
+// AccountView is an account with masked secrets, as it's shown by the admin API and CLI.
+type AccountView struct {
+	ID           string                 `json:"id"`
+	Resource     string                 `json:"resource"`
+	SlackChannel string                 `json:"slack_channel"`
+	Props        map[string]interface{} `json:"props"`
+	CreateAt     time.Time              `json:"create_at"`
+	UpdateAt     *time.Time             `json:"update_at,omitempty"`
+	DeleteAt     *time.Time             `json:"delete_at,omitempty"`
+}
+
+func (v AccountView) Disabled() bool {
+	return v.DeleteAt != nil
+}
+
+type AccountRequest struct {
+	Resource     string                 `json:"resource"`
+	SlackChannel string                 `json:"slack_channel"`
+	Props        map[string]interface{} `json:"props"`
+}
+
+// AccountsService is shared by the admin API and the accounts commands.
+type AccountsService struct {
+	db contract.Storage
+}
+
+func NewAccountsService(db contract.Storage) *AccountsService {
+	return &AccountsService{
+		db: db,
+	}
+}
+
+// List returns accounts of the resource, or of all resources when it's empty; disabled accounts are included on demand.
+func (s *AccountsService) List(ctx context.Context, resource string, withDisabled bool) ([]AccountView, error) {
+	accounts, err := s.db.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]AccountView, 0, len(accounts))
+	for _, account := range accounts {
+		if resource != "" && account.Resource != resource {
+			continue
+		}
+		if account.DeleteAt != nil && !withDisabled {
+			continue
+		}
+		views = append(views, newAccountView(account))
+	}
+
+	return views, nil
+}
+
+func (s *AccountsService) Get(ctx context.Context, resource, slackChannel string) (AccountView, error) {
+	account, err := s.findActive(ctx, resource, slackChannel)
+	if err != nil {
+		return AccountView{}, err
+	}
+
+	return newAccountView(*account), nil
+}
+
+func (s *AccountsService) Add(ctx context.Context, request AccountRequest) (AccountView, error) {
+	var problems []string
+
+	if request.SlackChannel == "" {
+		problems = append(problems, "slack_channel is required")
+	} else if len(request.SlackChannel) > maxSlackChannelLength {
+		problems = append(problems, fmt.Sprintf("slack_channel must not be longer than %d", maxSlackChannelLength))
+	}
+	if err := model.ValidateAccountProps(request.Resource, request.Props); err != nil {
+		var validationErr *model.AccountValidationError
+		if !errors.As(err, &validationErr) {
+			return AccountView{}, err
+		}
+		problems = append(problems, validationErr.Problems...)
+	}
+	if len(problems) > 0 {
+		return AccountView{}, &model.AccountValidationError{Problems: problems}
+	}
+
+	_, err := s.findActive(ctx, request.Resource, request.SlackChannel)
+	if err == nil {
+		return AccountView{}, fmt.Errorf("%s account for %s: %w", request.Resource, request.SlackChannel, ErrAccountExists)
+	} else if !errors.Is(err, ErrAccountNotFound) {
+		return AccountView{}, err
+	}
+
+	account := &model.Account{
+		Resource:     request.Resource,
+		SlackChannel: request.SlackChannel,
+		Props:        request.Props,
+	}
+	if err = s.db.CreateAccount(ctx, account); err != nil {
+		return AccountView{}, err
+	}
+
+	return newAccountView(*account), nil
+}
+
+// Update merges props into the account props; the merged props must stay valid.
+func (s *AccountsService) Update(
+	ctx context.Context,
+	resource, slackChannel string,
+	props map[string]interface{},
+) (AccountView, error) {
+	account, err := s.findActive(ctx, resource, slackChannel)
+	if err != nil {
+		return AccountView{}, err
+	}
+
+	stored := account.GetProps()
+	for _, key := range model.SecretProps[resource] {
+		value, ok := props[key]
+		if !ok {
+			continue
+		}
+		if value = model.UnmaskSecret(value, stored[key]); value == nil {
+			delete(props, key)
+		} else {
+			props[key] = value
+		}
+	}
+
+	merged := account.GetProps()
+	for key, value := range props {
+		merged[key] = value
+	}
+	if err = model.ValidateAccountProps(resource, merged); err != nil {
+		return AccountView{}, err
+	}
+
+	if err = s.db.UpdateAccountProps(ctx, resource, account.SlackChannel, props); err != nil {
+		return AccountView{}, err
+	}
+
+	return s.Get(ctx, resource, account.SlackChannel)
+}
+
+func (s *AccountsService) Disable(ctx context.Context, resource, slackChannel string) error {
+	account, err := s.findActive(ctx, resource, slackChannel)
+	if err != nil {
+		return err
+	}
+
+	return s.db.DisableAccount(ctx, resource, account.SlackChannel)
+}
+
+func (s *AccountsService) findActive(ctx context.Context, resource, slackChannel string) (*model.Account, error) {
+	accounts, err := s.db.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, account := range accounts {
+		if account.Resource == resource && account.DeleteAt == nil && strings.EqualFold(account.SlackChannel, slackChannel) {
+			return &accounts[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s account for %s: %w", resource, slackChannel, ErrAccountNotFound)
+}
+
+func newAccountView(account model.Account) AccountView {
+	return AccountView{
+		ID:           account.Id,
+		Resource:     account.Resource,
+		SlackChannel: account.SlackChannel,
+		Props:        model.MaskSecrets(account.Resource, account.GetProps()),
+		CreateAt:     account.CreateAt,
+		UpdateAt:     account.UpdateAt,
+		DeleteAt:     account.DeleteAt,
+	}
+}
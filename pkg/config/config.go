@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -17,6 +18,16 @@ var envBindings = []string{
 	"postgres.url",
 	"postgres.insecure",
 	"otel.exporter.endpoint",
+	"retry.attempts",
+	"retry.delay",
+	"accounts.refresh",
+	"webhooks.dedupttl",
+	"retention.outbox",
+	"retention.processedmessages",
+	"encryption.active",
+	"encryption.keys",
+	"encryption.keyfile",
+	"admin.token",
 }
 
 type Config struct {
@@ -39,6 +50,28 @@ type Config struct {
 	Metrics struct {
 		Port string `yaml:"port"`
 	} `yaml:"metrics"`
+	Retry struct {
+		Attempts int           `yaml:"attempts"`
+		Delay    time.Duration `yaml:"delay"`
+	} `yaml:"retry"`
+	Accounts struct {
+		Refresh time.Duration `yaml:"refresh"`
+	} `yaml:"accounts"`
+	Webhooks struct {
+		DedupTTL time.Duration `yaml:"dedupttl"`
+	} `yaml:"webhooks"`
+	Retention struct {
+		Outbox            time.Duration `yaml:"outbox"`
+		ProcessedMessages time.Duration `yaml:"processedmessages"`
+	} `yaml:"retention"`
+	Encryption struct {
+		Active  string `yaml:"active"`
+		Keys    string `yaml:"keys"`
+		KeyFile string `yaml:"keyfile"`
+	} `yaml:"encryption"`
+	Admin struct {
+		Token string `yaml:"token"`
+	} `yaml:"admin"`
 	OTel struct {
 		Exporter struct {
 			Endpoint string `yaml:"endpoint"`
@@ -60,6 +93,16 @@ func NewConfig() (*Config, error) {
 	return c, nil
 }
 
+// GetRabbitMQURL returns the URL the same way amqpwrapper builds it from components.
+func (c *Config) GetRabbitMQURL() string {
+	if c.RabbitMQ.URL != "" {
+		return c.RabbitMQ.URL
+	}
+
+	return "amqp://" + c.RabbitMQ.User + ":" + c.RabbitMQ.Password + "@" +
+		c.RabbitMQ.Host + ":" + c.RabbitMQ.Port + c.RabbitMQ.Vhost
+}
+
 func bindEnvs(envKeys []string) error {
 	for _, envKey := range envKeys {
 		if err := viper.BindEnv(envKey); err != nil {
@@ -1,58 +1,139 @@
 package consumer
 
 import (
+	"fmt"
+
 	"github.com/astreter/amqpwrapper/v2"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"x-qdo/jiraclick/pkg/config"
 	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
 	"x-qdo/jiraclick/pkg/provider/clickup"
 	"x-qdo/jiraclick/pkg/provider/jira"
 	"x-qdo/jiraclick/pkg/publisher"
+	"x-qdo/jiraclick/pkg/syncer"
 )
 
-var actionRoutingKeys = [3]contract.RoutingKey{
+var actionRoutingKeys = [4]contract.RoutingKey{
 	contract.TaskCreateClickUp,
 	contract.TaskCreateJira,
 	contract.TaskUpdateClickUp,
+	contract.TaskUpdateJira,
 }
 
 type ActionsConsumer struct {
+	cfg             *config.Config
 	queueProvider   *amqpwrapper.RabbitChannel
 	clickupProvider *clickup.ConnectorPool
 	jiraProvider    *jira.ConnectorPool
+	db              contract.Storage
 }
 
 func NewActionsConsumer(
+	cfg *config.Config,
 	jiraProvider *jira.ConnectorPool,
 	queueProvider *amqpwrapper.RabbitChannel,
 	clickup *clickup.ConnectorPool,
+	db contract.Storage,
 ) (*ActionsConsumer, error) {
 	if err := queueProvider.DefineExchange(contract.BRPActionsExchange, true); err != nil {
 		return nil, err
 	}
 
 	return &ActionsConsumer{
+		cfg:             cfg,
 		queueProvider:   queueProvider,
 		clickupProvider: clickup,
 		jiraProvider:    jiraProvider,
+		db:              db,
 	}, nil
 }
 
 func (c *ActionsConsumer) SetUpListeners() error {
-	p, err := publisher.NewEventPublisher(c.queueProvider)
+	p, err := publisher.NewOutboxEventPublisher(c.queueProvider, c.db)
+	if err != nil {
+		return err
+	}
+
+	validator, err := newValidator(c.queueProvider)
 	if err != nil {
 		return err
 	}
 
+	retry := newRetryPolicy(c.cfg, c.queueProvider, c.db, p)
+	if err = declareTopology(c.cfg.GetRabbitMQURL(), actionRoutingKeys[:], retry, validator); err != nil {
+		return err
+	}
+
 	for _, key := range actionRoutingKeys {
-		action, err := MakeAction(key, c.jiraProvider, c.clickupProvider, p)
+		action, err := MakeAction(key, c.jiraProvider, c.clickupProvider, p, c.db)
 		if err != nil {
 			return err
 		}
 		queueRoutingKey := string(key)
-		err = c.queueProvider.SetUpConsumer(contract.BRPActionsExchange, queueRoutingKey, action.ProcessAction)
+		err = c.queueProvider.SetUpConsumer(contract.BRPActionsExchange, queueRoutingKey, retry.wrap(key, validator.wrap(key, action)))
 		if err != nil {
 			return err
 		}
 	}
 
+	return c.setUpWebhooksListener(p)
+}
+
+// setUpWebhooksListener consumes ClickUp and Jira webhooks accepted by the HTTP handler. Webhooks aren't
+// BRP actions, so their retry policy only dead-letters them without task.failed events.
+func (c *ActionsConsumer) setUpWebhooksListener(p *publisher.EventPublisher) error {
+	if err := c.queueProvider.DefineExchange(contract.WebhooksExchange, false); err != nil {
+		return err
+	}
+
+	retry := newRetryPolicy(c.cfg, c.queueProvider, c.db, nil)
+	if err := declareTopology(c.cfg.GetRabbitMQURL(), []contract.RoutingKey{contract.Webhooks}, retry); err != nil {
+		return err
+	}
+
+	taskSyncer := syncer.NewSyncer(c.jiraProvider, c.clickupProvider, c.db, p)
+	clickUpAction, err := NewClickUpWebhookAction(c.cfg, c.clickupProvider, p, c.db, taskSyncer)
+	if err != nil {
+		return err
+	}
+	jiraAction, err := NewJiraWebhookAction(c.cfg, p, c.db, taskSyncer)
+	if err != nil {
+		return err
+	}
+	action := newWebhooksAction(map[string]contract.Action{
+		model.ClickUpResource: clickUpAction,
+		model.JiraResource:    jiraAction,
+	})
+
+	return c.queueProvider.SetUpConsumer(contract.WebhooksExchange, string(contract.Webhooks), retry.wrap(contract.Webhooks, action))
+}
+
+type topology interface {
+	declare(ch *amqp.Channel, keys []contract.RoutingKey) error
+}
+
+// declareTopology declares queues of the routing keys, which are needed besides the action queues.
+// amqpwrapper can't declare queues with arguments, so a separate short-living connection is used.
+func declareTopology(url string, keys []contract.RoutingKey, topologies ...topology) error {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return fmt.Errorf("RabbitMQ: failed to connect for topology declaration: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("RabbitMQ: failed to open a channel for topology declaration: %w", err)
+	}
+	defer ch.Close()
+
+	for _, t := range topologies {
+		if err = t.declare(ch, keys); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -11,7 +11,7 @@ type inputBody struct {
 	ID          string `json:"id"`
 	DisplayName string `json:"displayName"`
 	Data        struct {
-		Payload string `json:"payload"`
+		Payload actionPayload `json:"payload"`
 	} `json:"data"`
 }
 
@@ -20,6 +20,7 @@ func MakeAction(
 	jira *jira.ConnectorPool,
 	clickup *clickup.ConnectorPool,
 	publisher *publisher.EventPublisher,
+	db contract.Storage,
 ) (contract.Action, error) {
 	var (
 		action contract.Action
@@ -28,11 +29,13 @@ func MakeAction(
 
 	switch key {
 	case contract.TaskCreateClickUp:
-		action, err = NewTaskCreateClickupAction(clickup, publisher)
+		action, err = NewTaskCreateClickupAction(clickup, publisher, db)
 	case contract.TaskCreateJira:
-		action, err = NewTaskCreateJiraAction(jira, publisher)
+		action, err = NewTaskCreateJiraAction(jira, publisher, db)
 	case contract.TaskUpdateClickUp:
-		action, err = NewTaskUpdateClickupAction(clickup, publisher)
+		action, err = NewTaskUpdateClickupAction(clickup, publisher, db)
+	case contract.TaskUpdateJira:
+		action, err = NewTaskUpdateJiraAction(jira, publisher, db)
 	}
 
 	if err != nil {
@@ -0,0 +1,72 @@
+package consumer
+
+import (
+	"context"
+
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+)
+
+// getProcessedMessage returns the stored result of the message, messages without an ID are never deduplicated.
+func getProcessedMessage(ctx context.Context, db contract.Storage, input inputBody) (*model.ProcessedMessage, error) {
+	if input.ID == "" {
+		return nil, nil
+	}
+
+	return db.GetProcessedMessage(ctx, input.ID)
+}
+
+func saveProcessedMessage(
+	ctx context.Context,
+	db contract.Storage,
+	key contract.RoutingKey,
+	input inputBody,
+	result model.TaskPayload,
+) error {
+	if input.ID == "" {
+		return nil
+	}
+
+	return db.CreateProcessedMessage(ctx, &model.ProcessedMessage{
+		MessageID: input.ID,
+		Action:    string(key),
+		Result:    &result,
+	})
+}
+
+// commitResult stores the processed message, the task link and the events sent by publish, unless they're nil,
+// in one transaction. A processed message therefore means its events are sent, so a redelivered one is just acked.
+func commitResult(
+	ctx context.Context,
+	db contract.Storage,
+	key contract.RoutingKey,
+	input inputBody,
+	result model.TaskPayload,
+	link *model.TaskLink,
+	publish func(ctx context.Context, payload model.TaskPayload) error,
+) error {
+	if err := db.Begin(ctx); err != nil {
+		return err
+	}
+
+	if err := saveProcessedMessage(ctx, db, key, input, result); err != nil {
+		_ = db.Rollback(ctx)
+		return err
+	}
+
+	if link != nil {
+		if err := db.CreateTaskLink(ctx, link); err != nil {
+			_ = db.Rollback(ctx)
+			return err
+		}
+	}
+
+	if publish != nil {
+		if err := publish(ctx, result); err != nil {
+			_ = db.Rollback(ctx)
+			return err
+		}
+	}
+
+	return db.Commit(ctx)
+}
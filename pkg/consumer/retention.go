@@ -0,0 +1,64 @@
+package consumer
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+)
+
+const (
+	defaultOutboxRetention           = 7 * 24 * time.Hour
+	defaultProcessedMessageRetention = 30 * 24 * time.Hour
+	retentionCleanupInterval         = time.Hour
+)
+
+// RetentionCleaner deletes sent outbox events and processed messages once they're kept long enough.
+// Processed messages must outlive redeliveries of their messages, including all retries.
+type RetentionCleaner struct {
+	db                contract.Storage
+	outbox            time.Duration
+	processedMessages time.Duration
+}
+
+func NewRetentionCleaner(cfg *config.Config, db contract.Storage) *RetentionCleaner {
+	c := &RetentionCleaner{
+		db:                db,
+		outbox:            cfg.Retention.Outbox,
+		processedMessages: cfg.Retention.ProcessedMessages,
+	}
+	if c.outbox <= 0 {
+		c.outbox = defaultOutboxRetention
+	}
+	if c.processedMessages <= 0 {
+		c.processedMessages = defaultProcessedMessageRetention
+	}
+
+	return c
+}
+
+func (c *RetentionCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(retentionCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if deleted, err := c.db.DeleteSentOutboxEvents(ctx, c.outbox); err != nil {
+				logrus.Errorf("retention cleaner: outbox events: %s", err.Error())
+			} else if deleted > 0 {
+				logrus.Debugf("retention cleaner: %d outbox events deleted", deleted)
+			}
+			if deleted, err := c.db.DeleteProcessedMessages(ctx, c.processedMessages); err != nil {
+				logrus.Errorf("retention cleaner: processed messages: %s", err.Error())
+			} else if deleted > 0 {
+				logrus.Debugf("retention cleaner: %d processed messages deleted", deleted)
+			}
+		}
+	}
+}
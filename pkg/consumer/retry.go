@@ -0,0 +1,278 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/astreter/amqpwrapper/v2"
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/metrics"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/publisher"
+)
+
+const (
+	defaultRetryAttempts = 5
+	defaultRetryDelay    = 10 * time.Second
+	maxRetryDelay        = 6 * time.Hour
+
+	retryCountHeader = "x-retry-count"
+	lastErrorHeader  = "x-last-error"
+)
+
+// retryPolicy reschedules failed deliveries through per-attempt delay queues, which dead-letter
+// messages back to the action queue once their TTL expires. After the last attempt, or at once when
+// a retry can't help, a delivery is parked in the dead queue of its action and a task.failed event
+// is sent to BRP, unless the policy has no publisher. A delivery which can't be published to those queues is stored
+// in the outbox and relayed later, so it's acknowledged either way.
+type retryPolicy struct {
+	queueProvider *amqpwrapper.RabbitChannel
+	db            contract.Storage
+	publisher     *publisher.EventPublisher
+	attempts      int
+	delay         time.Duration
+}
+
+func newRetryPolicy(
+	cfg *config.Config,
+	queueProvider *amqpwrapper.RabbitChannel,
+	db contract.Storage,
+	p *publisher.EventPublisher,
+) *retryPolicy {
+	policy := &retryPolicy{
+		queueProvider: queueProvider,
+		db:            db,
+		publisher:     p,
+		attempts:      cfg.Retry.Attempts,
+		delay:         cfg.Retry.Delay,
+	}
+	if policy.attempts <= 0 {
+		policy.attempts = defaultRetryAttempts
+	}
+	if policy.delay <= 0 {
+		policy.delay = defaultRetryDelay
+	}
+
+	return policy
+}
+
+// retryQueueName contains the delay, since the TTL of a declared queue can't be changed when the retry config changes.
+func (p *retryPolicy) retryQueueName(key contract.RoutingKey, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d.%dms", key, attempt, p.attemptDelay(attempt).Milliseconds())
+}
+
+func deadQueueName(key contract.RoutingKey) string {
+	return string(key) + ".dead"
+}
+
+// declare declares the retry exchange, delay queues and dead queues of the routing keys.
+func (p *retryPolicy) declare(ch *amqp.Channel, keys []contract.RoutingKey) error {
+	err := ch.ExchangeDeclare(contract.RetryExchange, "direct", true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("RabbitMQ: failed to declare %s exchange: %w", contract.RetryExchange, err)
+	}
+
+	for _, key := range keys {
+		for attempt := 1; attempt <= p.attempts; attempt++ {
+			name := p.retryQueueName(key, attempt)
+			_, err = ch.QueueDeclare(name, true, false, false, false, amqp.Table{
+				"x-message-ttl": p.attemptDelay(attempt).Milliseconds(),
+				// the default exchange routes the message straight back to the action queue
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": string(key),
+			})
+			if err != nil {
+				return fmt.Errorf("RabbitMQ: failed to declare a queue %s: %w", name, err)
+			}
+			if err = ch.QueueBind(name, name, contract.RetryExchange, false, nil); err != nil {
+				return fmt.Errorf("RabbitMQ: failed to bind a queue %s: %w", name, err)
+			}
+		}
+
+		name := deadQueueName(key)
+		if _, err = ch.QueueDeclare(name, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("RabbitMQ: failed to declare a queue %s: %w", name, err)
+		}
+		if err = ch.QueueBind(name, name, contract.RetryExchange, false, nil); err != nil {
+			return fmt.Errorf("RabbitMQ: failed to bind a queue %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// attemptDelay doubles the delay with every attempt up to maxRetryDelay.
+func (p *retryPolicy) attemptDelay(attempt int) time.Duration {
+	if p.delay >= maxRetryDelay {
+		return p.delay
+	}
+
+	delay := p.delay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay
+}
+
+// wrap returns a listener which acknowledges a failed delivery once it is rescheduled or parked.
+func (p *retryPolicy) wrap(key contract.RoutingKey, action contract.Action) amqpwrapper.MessageListener {
+	return func(ctx context.Context, delivery amqp.Delivery) error {
+		started := time.Now()
+
+		actionErr := action.ProcessAction(ctx, delivery)
+		if actionErr == nil {
+			metrics.ActionProcessed(string(key), metrics.ActionSucceeded, started)
+			return nil
+		}
+		if errors.Is(actionErr, errMessageParked) {
+			metrics.ActionProcessed(string(key), metrics.ActionParked, started)
+			return nil
+		}
+
+		span := trace.SpanFromContext(ctx)
+		attempt := retryCount(delivery) + 1
+		headers := []amqpwrapper.Header{
+			{Key: retryCountHeader, Value: strconv.Itoa(attempt)},
+			{Key: lastErrorHeader, Value: actionErr.Error()},
+			{Key: schemaVersionHeader, Value: schemaVersion(delivery)},
+		}
+
+		permanent := isPermanent(actionErr)
+		if permanent {
+			span.AddEvent("failure is permanent, delivery isn't retried")
+		}
+
+		if attempt <= p.attempts && !permanent {
+			name := p.retryQueueName(key, attempt)
+			if err := p.send(ctx, delivery, name, headers); err != nil {
+				metrics.ActionProcessed(string(key), metrics.ActionFailed, started)
+				return errors.Wrap(actionErr, fmt.Sprintf("delivery can't be rescheduled: %s", err.Error()))
+			}
+			metrics.ActionProcessed(string(key), metrics.ActionRetried, started)
+			span.AddEvent("delivery rescheduled", trace.WithAttributes(
+				attribute.Int("attempt", attempt),
+				attribute.String("queue", name),
+			))
+			return nil
+		}
+
+		if err := p.send(ctx, delivery, deadQueueName(key), headers); err != nil {
+			metrics.ActionProcessed(string(key), metrics.ActionFailed, started)
+			return errors.Wrap(actionErr, fmt.Sprintf("delivery can't be dead-lettered: %s", err.Error()))
+		}
+		metrics.ActionProcessed(string(key), metrics.ActionDead, started)
+		span.AddEvent("delivery dead-lettered", trace.WithAttributes(attribute.Int("attempts", attempt)))
+		if p.publisher == nil {
+			return nil
+		}
+
+		failure, slackChannel := newTaskFailure(key, delivery, attempt, actionErr)
+		if err := p.publisher.TaskFailed(ctx, failure, slackChannel); err != nil {
+			span.RecordError(err)
+		}
+
+		return nil
+	}
+}
+
+// send publishes the delivery to the retry exchange and falls back to the outbox when RabbitMQ doesn't take it.
+// Only when both fail, the delivery is nacked; it's redelivered by RabbitMQ anyway if the channel is closed.
+func (p *retryPolicy) send(ctx context.Context, delivery amqp.Delivery, queue string, headers []amqpwrapper.Header) error {
+	span := trace.SpanFromContext(ctx)
+
+	err := p.queueProvider.Publish(ctx, json.RawMessage(delivery.Body), contract.RetryExchange, queue, headers...)
+	if err == nil {
+		return nil
+	}
+	span.RecordError(err)
+
+	traceHeaders := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceHeaders)
+
+	event := &model.OutboxEvent{
+		Exchange:     contract.RetryExchange,
+		RoutingKey:   queue,
+		Payload:      delivery.Body,
+		TraceHeaders: traceHeaders,
+		Headers:      make(map[string]string, len(headers)),
+	}
+	for _, header := range headers {
+		event.Headers[header.Key] = header.Value
+	}
+	if outboxErr := p.db.CreateOutboxEvent(ctx, event); outboxErr != nil {
+		return fmt.Errorf("%s, outbox: %w", err.Error(), outboxErr)
+	}
+	span.AddEvent("delivery stored in outbox", trace.WithAttributes(attribute.String("queue", queue)))
+
+	return nil
+}
+
+// isPermanent reports whether a retry can't help: the payload is not valid, the tenant is unknown,
+// or ClickUp rejects the request.
+func isPermanent(err error) bool {
+	var (
+		unknownTenant *model.UnknownTenantError
+		syntaxErr     *json.SyntaxError
+		typeErr       *json.UnmarshalTypeError
+	)
+
+	return errors.Is(err, errInvalidPayload) ||
+		errors.As(err, &unknownTenant) ||
+		errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr) ||
+		clickup.IsPermanent(err)
+}
+
+func retryCount(delivery amqp.Delivery) int {
+	if value, ok := delivery.Headers[retryCountHeader].(string); ok {
+		if count, err := strconv.Atoi(value); err == nil {
+			return count
+		}
+	}
+
+	return 0
+}
+
+func newTaskFailure(
+	key contract.RoutingKey,
+	delivery amqp.Delivery,
+	attempts int,
+	err error,
+) (model.TaskFailure, string) {
+	var input inputBody
+
+	failure := model.TaskFailure{
+		Action:   string(key),
+		Attempts: attempts,
+		Error:    err.Error(),
+	}
+
+	if json.Unmarshal(delivery.Body, &input) != nil {
+		return failure, ""
+	}
+	failure.MessageID = input.ID
+
+	payload := new(model.TaskPayload)
+	if json.Unmarshal([]byte(input.Data.Payload), payload) != nil {
+		return failure, ""
+	}
+	failure.Payload = payload
+
+	return failure, payload.SlackChannel
+}
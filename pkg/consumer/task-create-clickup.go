@@ -18,12 +18,14 @@ import (
 type TaskCreateClickupAction struct {
 	client    *clickup.ConnectorPool
 	publisher *publisher.EventPublisher
+	db        contract.Storage
 }
 
-func NewTaskCreateClickupAction(clickup *clickup.ConnectorPool, p *publisher.EventPublisher) (contract.Action, error) {
+func NewTaskCreateClickupAction(clickup *clickup.ConnectorPool, p *publisher.EventPublisher, db contract.Storage) (contract.Action, error) {
 	return &TaskCreateClickupAction{
 		client:    clickup,
 		publisher: p,
+		db:        db,
 	}, nil
 }
 
@@ -51,9 +53,28 @@ func (a *TaskCreateClickupAction) ProcessAction(ctx context.Context, delivery am
 		return err
 	}
 
-	request := a.generateTaskRequest(ctx, &payload)
+	processed, err := getProcessedMessage(ctx, a.db, input)
+	if err != nil {
+		err = errors.Wrap(err, "Can't check whether the message is processed")
+		span.RecordError(err)
+		return err
+	}
+	if processed != nil {
+		span.AddEvent("message is already processed")
+		return nil
+	}
+
+	// a tenant served by the default account is reported as well
+	reportUnknownTenant(ctx, a.publisher, contract.TaskCreateClickUp, delivery, a.client.CheckTenant(payload.SlackChannel))
+	client, err := a.client.GetInstance(payload.SlackChannel)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	request := a.generateTaskRequest(ctx, client, &payload)
 	span.AddEvent("Request payload generated")
-	task, err = a.client.GetInstance(payload.SlackChannel).CreateTask(ctx, request)
+	task, err = client.CreateTask(ctx, request)
 	if err != nil {
 		err = errors.Wrap(err, "Can't create a task in ClickUp")
 		span.RecordError(err)
@@ -64,18 +85,24 @@ func (a *TaskCreateClickupAction) ProcessAction(ctx context.Context, delivery am
 
 	payload.ClickupID = task.ID
 	payload.Details["clickup_url"] = task.URL
-	err = a.publisher.ClickUpTaskCreated(ctx, payload)
+
+	err = commitResult(ctx, a.db, contract.TaskCreateClickUp, input, payload, model.NewTaskLink(payload), a.publisher.ClickUpTaskCreated)
 	if err != nil {
+		err = errors.Wrap(err, "Can't store the result")
 		span.RecordError(err)
 		return err
 	}
 
-	span.AddEvent("result sent to BRP")
+	span.AddEvent("result stored in outbox with the task link")
 
 	return nil
 }
 
-func (a *TaskCreateClickupAction) generateTaskRequest(ctx context.Context, payload *model.TaskPayload) *clickup.PutClickUpTaskRequest {
+func (a *TaskCreateClickupAction) generateTaskRequest(
+	ctx context.Context,
+	client clickup.ClientInterface,
+	payload *model.TaskPayload,
+) *clickup.PutClickUpTaskRequest {
 	request := new(clickup.PutClickUpTaskRequest)
 
 	ctx, span := otel.Tracer("clickup action").Start(ctx, "generateTaskRequest")
@@ -83,7 +110,7 @@ func (a *TaskCreateClickupAction) generateTaskRequest(ctx context.Context, paylo
 
 	request.Name = payload.Title
 	request.NotifyAll = false
-	request.Status = a.client.GetInstance(payload.SlackChannel).GetInitialTaskStatus(ctx)
+	request.Status = client.GetInitialTaskStatus(ctx)
 	request.Description = payload.Description + "\n" + payload.AC
 	request.AddCustomField(clickup.RequestedBy, payload.SlackReporter)
 	request.AddCustomField(clickup.SlackLink, payload.Details["slack"])
@@ -17,12 +17,14 @@ import (
 type TaskCreateJiraAction struct {
 	client    *jira.ConnectorPool
 	publisher *publisher.EventPublisher
+	db        contract.Storage
 }
 
-func NewTaskCreateJiraAction(jira *jira.ConnectorPool, p *publisher.EventPublisher) (contract.Action, error) {
+func NewTaskCreateJiraAction(jira *jira.ConnectorPool, p *publisher.EventPublisher, db contract.Storage) (contract.Action, error) {
 	return &TaskCreateJiraAction{
 		client:    jira,
 		publisher: p,
+		db:        db,
 	}, nil
 }
 
@@ -49,10 +51,35 @@ func (a *TaskCreateJiraAction) ProcessAction(ctx context.Context, delivery amqp.
 		return err
 	}
 
-	task := a.generateTaskRequest(payload)
+	processed, err := getProcessedMessage(ctx, a.db, input)
+	if err != nil {
+		err = errors.Wrap(err, "Can't check whether the message is processed")
+		span.RecordError(err)
+		return err
+	}
+	if processed != nil {
+		span.AddEvent("message is already processed")
+		return nil
+	}
+
+	// a tenant served by the default account is reported as well
+	reportUnknownTenant(ctx, a.publisher, contract.TaskCreateJira, delivery, a.client.CheckTenant(payload.SlackChannel))
+	client, err := a.client.GetInstance(payload.SlackChannel)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	account, err := a.client.GetAccount(payload.SlackChannel)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	task := a.generateTaskRequest(account, payload)
 	span.AddEvent("Request payload generated")
 
-	response, err := a.client.GetInstance(payload.SlackChannel).CreateIssue(ctx, task)
+	response, err := client.CreateIssue(ctx, task)
 	if err != nil {
 		err = errors.Wrap(err, "Can't create task in Jira")
 		span.RecordError(err)
@@ -63,30 +90,35 @@ func (a *TaskCreateJiraAction) ProcessAction(ctx context.Context, delivery amqp.
 
 	payload.JiraID = response.ID
 	payload.Details["jira_url"] = response.URL
-	err = a.publisher.JiraTaskCreated(ctx, payload)
+
+	err = commitResult(ctx, a.db, contract.TaskCreateJira, input, payload, model.NewTaskLink(payload), a.publisher.JiraTaskCreated)
 	if err != nil {
+		err = errors.Wrap(err, "Can't store the result")
 		span.RecordError(err)
 		return err
 	}
 
-	span.AddEvent("result sent to BRP")
+	span.AddEvent("result stored in outbox with the task link")
 
 	return nil
 }
 
-func (a *TaskCreateJiraAction) generateTaskRequest(payload model.TaskPayload) *jira.Task {
+func (a *TaskCreateJiraAction) generateTaskRequest(account model.JiraAccount, payload model.TaskPayload) *jira.Task {
 	task := new(jira.Task)
 
 	task.Title = payload.Title
 	task.Reporter = payload.GetReporterEmail()
-	task.Type = "Story"
+	task.Type = account.GetIssueType(payload.Type)
 	task.Description = payload.Description + "\n\n" + payload.AC
 
 	customFields := tcontainer.NewMarshalMap()
-	customFields["customfield_10101"] = map[string]string{"value": "Internal"}
-	customFields["customfield_13400"] = map[string]string{"value": "No"}
-	customFields["customfield_15117"] = map[string]string{"value": "Team DevOps"}
+	for field, value := range account.CustomFields {
+		customFields[field] = value
+	}
 	task.CustomFields = customFields
+	task.Components = account.Components
+	task.Labels = account.Labels
+	task.Priority = account.Priority
 
 	return task
 }
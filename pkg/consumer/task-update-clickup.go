@@ -17,12 +17,14 @@ import (
 type TaskUpdateClickupAction struct {
 	client    *clickup.ConnectorPool
 	publisher *publisher.EventPublisher
+	db        contract.Storage
 }
 
-func NewTaskUpdateClickupAction(clickup *clickup.ConnectorPool, p *publisher.EventPublisher) (contract.Action, error) {
+func NewTaskUpdateClickupAction(clickup *clickup.ConnectorPool, p *publisher.EventPublisher, db contract.Storage) (contract.Action, error) {
 	return &TaskUpdateClickupAction{
 		client:    clickup,
 		publisher: p,
+		db:        db,
 	}, nil
 }
 
@@ -49,15 +51,51 @@ func (a *TaskUpdateClickupAction) ProcessAction(ctx context.Context, delivery am
 		return err
 	}
 
+	processed, err := getProcessedMessage(ctx, a.db, input)
+	if err != nil {
+		err = errors.Wrap(err, "Can't check whether the message is processed")
+		span.RecordError(err)
+		return err
+	}
+	if processed != nil {
+		span.AddEvent("message is already processed")
+		return nil
+	}
+
+	if payload.ClickupID == "" {
+		err = errors.Wrap(errInvalidPayload, "Can't update a task in ClickUp: clickup_id is not defined")
+		span.RecordError(err)
+		return err
+	}
+
+	// a tenant served by the default account is reported as well
+	reportUnknownTenant(ctx, a.publisher, contract.TaskUpdateClickUp, delivery, a.client.CheckTenant(payload.SlackChannel))
+	client, err := a.client.GetInstance(payload.SlackChannel)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
 	request := a.generateTaskRequest(payload)
 	span.AddEvent("Request payload generated")
-	err = a.client.GetInstance(payload.SlackChannel).UpdateTask(ctx, payload.ClickupID, request)
+	err = client.UpdateTask(ctx, payload.ClickupID, request)
 	if err != nil {
 		err = errors.Wrap(err, "Can't update a task in ClickUp")
 		span.RecordError(err)
 		return err
 	}
 
+	span.AddEvent("task updated")
+
+	err = commitResult(ctx, a.db, contract.TaskUpdateClickUp, input, payload, nil, nil)
+	if err != nil {
+		err = errors.Wrap(err, "Can't store the result")
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("result stored")
+
 	return nil
 }
 
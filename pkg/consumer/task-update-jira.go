@@ -0,0 +1,141 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/araddon/dateparse"
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/trivago/tgo/tcontainer"
+	"go.opentelemetry.io/otel"
+
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/jira"
+	"x-qdo/jiraclick/pkg/publisher"
+)
+
+type TaskUpdateJiraAction struct {
+	client    *jira.ConnectorPool
+	publisher *publisher.EventPublisher
+	db        contract.Storage
+}
+
+func NewTaskUpdateJiraAction(jira *jira.ConnectorPool, p *publisher.EventPublisher, db contract.Storage) (contract.Action, error) {
+	return &TaskUpdateJiraAction{
+		client:    jira,
+		publisher: p,
+		db:        db,
+	}, nil
+}
+
+func (a *TaskUpdateJiraAction) ProcessAction(ctx context.Context, delivery amqp.Delivery) error {
+	var (
+		input   inputBody
+		payload model.TaskPayload
+	)
+
+	ctx, span := otel.Tracer("jira action").Start(ctx, "ProcessAction")
+	defer span.End()
+
+	err := json.Unmarshal(delivery.Body, &input)
+	if err != nil {
+		err = errors.Wrap(err, "Can't unmarshall task body")
+		span.RecordError(err)
+		return err
+	}
+
+	err = json.Unmarshal([]byte(input.Data.Payload), &payload)
+	if err != nil {
+		err = errors.Wrap(err, "Can't unmarshall task body")
+		span.RecordError(err)
+		return err
+	}
+
+	processed, err := getProcessedMessage(ctx, a.db, input)
+	if err != nil {
+		err = errors.Wrap(err, "Can't check whether the message is processed")
+		span.RecordError(err)
+		return err
+	}
+	if processed != nil {
+		span.AddEvent("message is already processed")
+		return nil
+	}
+
+	if payload.JiraID == "" {
+		err = errors.Wrap(errInvalidPayload, "Can't update task in Jira: jira_id is not defined")
+		span.RecordError(err)
+		return err
+	}
+
+	// a tenant served by the default account is reported as well
+	reportUnknownTenant(ctx, a.publisher, contract.TaskUpdateJira, delivery, a.client.CheckTenant(payload.SlackChannel))
+	client, err := a.client.GetInstance(payload.SlackChannel)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	account, err := a.client.GetAccount(payload.SlackChannel)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	task := a.generateTaskRequest(account, payload)
+	span.AddEvent("Request payload generated")
+
+	err = client.UpdateIssue(ctx, task)
+	if err != nil {
+		err = errors.Wrap(err, "Can't update task in Jira")
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("issue updated")
+
+	err = commitResult(ctx, a.db, contract.TaskUpdateJira, input, payload, nil, a.publisher.JiraTaskUpdated)
+	if err != nil {
+		err = errors.Wrap(err, "Can't store the result")
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("result stored in outbox")
+
+	return nil
+}
+
+// generateTaskRequest sets the fields of the payload and the custom fields of the account; the other defaults
+// of the account, such as components, labels and priority, are applied on create only, so they aren't reset.
+func (a *TaskUpdateJiraAction) generateTaskRequest(account model.JiraAccount, payload model.TaskPayload) *jira.Task {
+	task := new(jira.Task)
+
+	task.ID = payload.JiraID
+	task.Title = payload.Title
+	task.Reporter = payload.GetReporterEmail()
+	description := make([]string, 0, 2)
+	for _, part := range []string{payload.Description, payload.AC} {
+		if part != "" {
+			description = append(description, part)
+		}
+	}
+	task.Description = strings.Join(description, "\n\n")
+
+	customFields := tcontainer.NewMarshalMap()
+	for field, value := range account.CustomFields {
+		customFields[field] = value
+	}
+	task.CustomFields = customFields
+
+	if payload.DueDate != "" {
+		if dueDate, err := dateparse.ParseAny(payload.DueDate); err == nil {
+			task.DueDate = &dueDate
+		}
+	}
+
+	return task
+}
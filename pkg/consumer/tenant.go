@@ -0,0 +1,39 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/publisher"
+)
+
+// reportUnknownTenant sends unknown_tenant event on the first attempt only, retries of the message don't repeat it.
+func reportUnknownTenant(
+	ctx context.Context,
+	p *publisher.EventPublisher,
+	key contract.RoutingKey,
+	delivery amqp.Delivery,
+	err error,
+) {
+	var unknown *model.UnknownTenantError
+	if !errors.As(err, &unknown) || retryCount(delivery) > 0 {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	err = p.UnknownTenant(ctx, model.UnknownTenant{
+		Resource: unknown.Resource,
+		Tenant:   unknown.Tenant,
+		Action:   string(key),
+	}, unknown.Tenant)
+	if err != nil {
+		span.RecordError(err)
+		return
+	}
+	span.AddEvent("unknown tenant reported")
+}
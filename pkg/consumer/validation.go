@@ -0,0 +1,261 @@
+package consumer
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/astreter/amqpwrapper/v2"
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/xeipuuv/gojsonschema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"x-qdo/jiraclick/pkg/contract"
+)
+
+const (
+	schemaVersionHeader = "x-schema-version"
+	parkingReasonHeader = "x-parking-reason"
+
+	// schemaV1 is the original envelope where data.payload is a JSON encoded string; it's assumed without the header.
+	schemaV1 = "1"
+	// schemaV2 is the envelope where data.payload is a JSON object.
+	schemaV2 = "2"
+)
+
+var (
+	// errMessageParked tells the retry policy an invalid message is parked and must be acknowledged without a retry.
+	errMessageParked = errors.New("message is parked")
+	// errInvalidPayload is returned by actions for payloads the schema accepts but the action can't process,
+	// such as v1 updates without a task ID; they're dead-lettered without retries.
+	errInvalidPayload = errors.New("payload is not valid")
+)
+
+//go:embed schema/*.json
+var schemaFS embed.FS
+
+// payloadSchemaFiles keep v1 as loose as decoding of the payload was before versioning, the stricter rules are v2 only.
+var payloadSchemaFiles = map[string]map[contract.RoutingKey]string{
+	schemaV1: {
+		contract.TaskCreateClickUp: "schema/task-create-clickup-v1.json",
+		contract.TaskCreateJira:    "schema/task-create-jira-v1.json",
+		contract.TaskUpdateClickUp: "schema/task-update-clickup-v1.json",
+		contract.TaskUpdateJira:    "schema/task-update-jira-v1.json",
+	},
+	schemaV2: {
+		contract.TaskCreateClickUp: "schema/task-create-clickup-v2.json",
+		contract.TaskCreateJira:    "schema/task-create-jira-v2.json",
+		contract.TaskUpdateClickUp: "schema/task-update-clickup-v2.json",
+		contract.TaskUpdateJira:    "schema/task-update-jira-v2.json",
+	},
+}
+
+var envelopeSchemaFiles = map[string]string{
+	schemaV1: "schema/envelope-v1.json",
+	schemaV2: "schema/envelope-v2.json",
+}
+
+// actionPayload accepts data.payload both as a JSON encoded string (v1) and as a JSON object (v2).
+type actionPayload string
+
+func (p *actionPayload) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var payload string
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+		*p = actionPayload(payload)
+		return nil
+	}
+
+	*p = actionPayload(data)
+
+	return nil
+}
+
+// validator checks inbound messages against the envelope schema of their version and the payload schema
+// of their routing key. Invalid messages are parked with the reason instead of being processed or retried.
+type validator struct {
+	queueProvider *amqpwrapper.RabbitChannel
+	envelopes     map[string]*gojsonschema.Schema
+	payloads      map[string]map[contract.RoutingKey]*gojsonschema.Schema
+}
+
+func newValidator(queueProvider *amqpwrapper.RabbitChannel) (*validator, error) {
+	v := &validator{
+		queueProvider: queueProvider,
+		envelopes:     make(map[string]*gojsonschema.Schema),
+		payloads:      make(map[string]map[contract.RoutingKey]*gojsonschema.Schema),
+	}
+
+	for version, file := range envelopeSchemaFiles {
+		schema, err := loadSchema(file)
+		if err != nil {
+			return nil, err
+		}
+		v.envelopes[version] = schema
+	}
+
+	for version, files := range payloadSchemaFiles {
+		v.payloads[version] = make(map[contract.RoutingKey]*gojsonschema.Schema, len(files))
+		for key, file := range files {
+			schema, err := loadSchema(file)
+			if err != nil {
+				return nil, err
+			}
+			v.payloads[version][key] = schema
+		}
+	}
+
+	return v, nil
+}
+
+func loadSchema(file string) (*gojsonschema.Schema, error) {
+	b, err := schemaFS.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(b))
+	if err != nil {
+		return nil, fmt.Errorf("schema %s is not valid: %w", file, err)
+	}
+
+	return schema, nil
+}
+
+func parkingQueueName(key contract.RoutingKey) string {
+	return string(key) + ".parked"
+}
+
+func (v *validator) declare(ch *amqp.Channel, keys []contract.RoutingKey) error {
+	if err := ch.ExchangeDeclare(contract.ParkingExchange, "direct", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("RabbitMQ: failed to declare %s exchange: %w", contract.ParkingExchange, err)
+	}
+
+	for _, key := range keys {
+		name := parkingQueueName(key)
+		if _, err := ch.QueueDeclare(name, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("RabbitMQ: failed to declare a queue %s: %w", name, err)
+		}
+		if err := ch.QueueBind(name, name, contract.ParkingExchange, false, nil); err != nil {
+			return fmt.Errorf("RabbitMQ: failed to bind a queue %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) wrap(key contract.RoutingKey, action contract.Action) contract.Action {
+	return &validatedAction{
+		validator: v,
+		key:       key,
+		action:    action,
+	}
+}
+
+func (v *validator) validate(key contract.RoutingKey, version string, body []byte) error {
+	var input inputBody
+
+	envelope, ok := v.envelopes[version]
+	if !ok {
+		return fmt.Errorf("schema version %q is not supported", version)
+	}
+	if !json.Valid(body) {
+		return errors.New("message body is not a valid JSON")
+	}
+
+	if err := checkSchema(envelope, gojsonschema.NewBytesLoader(body)); err != nil {
+		return errors.Wrap(err, "envelope")
+	}
+
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errors.Wrap(err, "envelope")
+	}
+
+	payload, ok := v.payloads[version][key]
+	if !ok {
+		return fmt.Errorf("schema v%s of %s is not defined", version, key)
+	}
+	if !json.Valid([]byte(input.Data.Payload)) {
+		return errors.New("payload: data.payload is not a valid JSON")
+	}
+
+	if err := checkSchema(payload, gojsonschema.NewStringLoader(string(input.Data.Payload))); err != nil {
+		return errors.Wrap(err, "payload")
+	}
+
+	return nil
+}
+
+func checkSchema(schema *gojsonschema.Schema, document gojsonschema.JSONLoader) error {
+	result, err := schema.Validate(document)
+	if err != nil {
+		return err
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	reasons := make([]string, 0, len(result.Errors()))
+	for _, e := range result.Errors() {
+		reasons = append(reasons, e.String())
+	}
+
+	return errors.New(strings.Join(reasons, "; "))
+}
+
+func (v *validator) park(ctx context.Context, key contract.RoutingKey, delivery amqp.Delivery, version, reason string) error {
+	var message interface{} = json.RawMessage(delivery.Body)
+
+	// a broken JSON can't be sent as is, so it's kept as a string
+	if !json.Valid(delivery.Body) {
+		message = string(delivery.Body)
+	}
+
+	return v.queueProvider.Publish(ctx, message, contract.ParkingExchange, parkingQueueName(key),
+		amqpwrapper.Header{Key: schemaVersionHeader, Value: version},
+		amqpwrapper.Header{Key: parkingReasonHeader, Value: reason},
+	)
+}
+
+func schemaVersion(delivery amqp.Delivery) string {
+	if version, ok := delivery.Headers[schemaVersionHeader].(string); ok && version != "" {
+		return version
+	}
+
+	return schemaV1
+}
+
+type validatedAction struct {
+	validator *validator
+	key       contract.RoutingKey
+	action    contract.Action
+}
+
+func (a *validatedAction) ProcessAction(ctx context.Context, delivery amqp.Delivery) error {
+	validationCtx, span := otel.Tracer("action validator").Start(ctx, "ValidateAction")
+
+	version := schemaVersion(delivery)
+	span.SetAttributes(attribute.String("schema version", version))
+
+	err := a.validator.validate(a.key, version, delivery.Body)
+	if err == nil {
+		span.End()
+		return a.action.ProcessAction(ctx, delivery)
+	}
+	defer span.End()
+
+	span.RecordError(err)
+	if parkErr := a.validator.park(validationCtx, a.key, delivery, version, err.Error()); parkErr != nil {
+		span.RecordError(parkErr)
+		return errors.Wrap(err, fmt.Sprintf("invalid message can't be parked: %s", parkErr.Error()))
+	}
+	span.AddEvent("message parked")
+
+	return errMessageParked
+}
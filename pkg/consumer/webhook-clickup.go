@@ -0,0 +1,402 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/publisher"
+	"x-qdo/jiraclick/pkg/syncer"
+)
+
+// ClickUpWebhookAction enriches a webhook accepted by the HTTP handler with the task from ClickUp,
+// sends the changes to BRP and syncs them to the linked Jira issue. History items which are seen
+// already or older than the applied change of their field are dropped.
+type ClickUpWebhookAction struct {
+	client    *clickup.ConnectorPool
+	publisher *publisher.EventPublisher
+	db        contract.Storage
+	syncer    *syncer.Syncer
+	dedupTTL  time.Duration
+}
+
+func NewClickUpWebhookAction(
+	cfg *config.Config,
+	clickup *clickup.ConnectorPool,
+	p *publisher.EventPublisher,
+	db contract.Storage,
+	syncer *syncer.Syncer,
+) (contract.Action, error) {
+	return &ClickUpWebhookAction{
+		client:    clickup,
+		publisher: p,
+		db:        db,
+		syncer:    syncer,
+		dedupTTL:  webhookDedupTTL(cfg),
+	}, nil
+}
+
+func (a *ClickUpWebhookAction) ProcessAction(ctx context.Context, delivery amqp.Delivery) error {
+	var webhook model.InboundWebhook
+
+	ctx, span := otel.Tracer("clickup webhook").Start(ctx, "ProcessAction")
+	defer span.End()
+
+	if err := json.Unmarshal(delivery.Body, &webhook); err != nil {
+		// a broken message can't be fixed by a retry
+		span.RecordError(errors.Wrap(err, "Can't unmarshall webhook"))
+		return nil
+	}
+	span.SetAttributes(
+		attribute.String("tenant", webhook.Tenant),
+		attribute.String("source", webhook.Source),
+	)
+
+	event, err := clickup.ParseEvent(ctx, webhook.Body)
+	if err != nil {
+		span.RecordError(errors.Wrap(err, "ClickUp webhook: body can't be parsed"))
+		return nil
+	} else if event == nil {
+		span.AddEvent("ClickUp webhook: webhook is without changes data")
+		return nil
+	}
+
+	return a.doAction(ctx, event, webhook.Tenant)
+}
+
+func (a *ClickUpWebhookAction) doAction(ctx context.Context, event *clickup.WebhookEvent, tenant string) error {
+	var changes model.TaskChanges
+
+	ctx, span := otel.Tracer("clickup webhook").Start(ctx, "doAction")
+	defer span.End()
+
+	client, err := a.client.GetInstance(tenant)
+	if err != nil {
+		err = errors.Wrap(err, "ClickUp webhook")
+		span.RecordError(err)
+		return err
+	}
+
+	task, err := client.GetTask(ctx, event.TaskID)
+	if errors.Is(err, clickup.ErrNotFound) {
+		span.AddEvent("task is not found in ClickUp, probably it has been deleted")
+		return nil
+	} else if err != nil {
+		err = errors.Wrap(err, "ClickUp webhook: can't get task")
+		span.RecordError(err)
+		return err
+	}
+	span.AddEvent("task retrieved from Clickup")
+
+	if event.Type == clickup.TaskUpdated && !isEventActual(task.DateUpdated, event.Changes[0].Date) {
+		span.AddEvent("task doesn't have `taskUpdated` status or update is not actual")
+		return nil
+	}
+
+	link, err := a.db.GetTaskLinkByClickUpID(ctx, event.TaskID)
+	if err != nil {
+		span.RecordError(errors.Wrap(err, "ClickUp webhook: can't get task link"))
+	}
+
+	var slackChannel string
+	if link != nil {
+		slackChannel = link.SlackChannel
+		span.AddEvent("slackChannel retrieved from task link")
+	} else {
+		slackChannel = task.GetSlackChannel(client.CustomFieldID(ctx, clickup.SlackLink))
+		span.AddEvent("slackChannel retrieved from task")
+	}
+	if slackChannel == "" {
+		err = errors.New("ClickUp webhook: slackChannel is not defined")
+		span.RecordError(err)
+		return err
+	}
+
+	// history items are claimed in the transaction of the event, so a failed delivery doesn't mark them applied
+	if err = a.db.Begin(ctx); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	event, err = a.freshChanges(ctx, event)
+	if err != nil {
+		_ = a.db.Rollback(ctx)
+		err = errors.Wrap(err, "ClickUp webhook: can't check history items")
+		span.RecordError(err)
+		return err
+	}
+	if len(event.Changes) == 0 {
+		_ = a.db.Rollback(ctx)
+		span.AddEvent("all history items are duplicated or stale")
+		return nil
+	}
+
+	changes = generateTaskChangesByEvent(event, task)
+	if link != nil {
+		changes.JiraID = link.JiraID
+	}
+	span.AddEvent("changes are defined")
+	err = a.publisher.ClickUpTaskUpdated(ctx, changes, slackChannel)
+	if err != nil {
+		_ = a.db.Rollback(ctx)
+		err = errors.Wrap(err, "ClickUp webhook: can't trigger changes event")
+		span.RecordError(err)
+		return err
+	}
+
+	if err = a.db.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if link != nil && link.JiraID != "" {
+		if err = a.syncer.SyncClickUpChanges(ctx, link, event, task); err != nil {
+			err = errors.Wrap(err, "ClickUp webhook: can't sync changes to Jira")
+			span.RecordError(err)
+			// the items are processed again on the retry, BRP tolerates the repeated event as any outbox redelivery
+			if releaseErr := a.db.ReleaseWebhookHistoryItems(ctx, historyItemIDs(event)); releaseErr != nil {
+				span.RecordError(releaseErr)
+				logrus.Error(errors.Wrap(releaseErr, "ClickUp webhook: can't release history items"))
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func historyItemIDs(event *clickup.WebhookEvent) []string {
+	ids := make([]string, 0, len(event.Changes))
+	for _, item := range event.Changes {
+		if item.ID != "" {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	return ids
+}
+
+// freshChanges returns the event without history items which are applied already or older than
+// the applied change of their field. Additive items, e.g. comments and tags, are deduplicated only.
+func (a *ClickUpWebhookAction) freshChanges(ctx context.Context, event *clickup.WebhookEvent) (*clickup.WebhookEvent, error) {
+	span := trace.SpanFromContext(ctx)
+
+	fresh := *event
+	fresh.Changes = make([]clickup.HistoryItem, 0, len(event.Changes))
+
+	for _, item := range event.Changes {
+		if item.ID != "" {
+			claimed, err := a.db.ClaimWebhookHistoryItem(ctx, &model.WebhookHistoryItem{
+				ID:     item.ID,
+				TaskID: event.TaskID,
+			}, a.dedupTTL)
+			if err != nil {
+				return nil, err
+			}
+			if !claimed {
+				span.AddEvent("duplicated history item dropped", trace.WithAttributes(attribute.String("id", item.ID)))
+				continue
+			}
+		}
+
+		if changedAt, ok := historyItemTime(item); ok && isOverwriteField(item.Field) {
+			applied, err := a.db.ApplyTaskFieldChange(ctx, &model.TaskFieldChange{
+				TaskID:        event.TaskID,
+				Field:         item.Field,
+				HistoryItemID: item.ID,
+				ChangedAt:     changedAt,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !applied {
+				span.AddEvent("stale history item dropped", trace.WithAttributes(
+					attribute.String("id", item.ID),
+					attribute.String("field", item.Field),
+				))
+				continue
+			}
+		}
+
+		fresh.Changes = append(fresh.Changes, item)
+	}
+
+	return &fresh, nil
+}
+
+func generateTaskChangesByEvent(event *clickup.WebhookEvent, task *clickup.Task) model.TaskChanges {
+	changes := model.TaskChanges{
+		Version:   model.TaskChangesVersion,
+		Type:      string(event.Type),
+		ClickupID: event.TaskID,
+	}
+	for _, historyItem := range event.Changes {
+		changes.Changes = append(changes.Changes, generateChange(event.Type, historyItem, task))
+		changes.Username = historyItem.User.Username
+	}
+
+	return changes
+}
+
+func generateChange(eventType clickup.EventType, historyItem clickup.HistoryItem, task *clickup.Task) model.Change {
+	before, after, err := historyItem.Decode()
+	if err != nil {
+		logrus.Warnf("ClickUp webhook: %s", err.Error())
+		before, after = historyItem.Before, historyItem.After
+	}
+
+	change := model.Change{
+		Field:    historyItem.Field,
+		Kind:     changeKind(historyItem.Field),
+		NewValue: unversionedValue(eventType, historyItem, task),
+		Value:    changeValue(after),
+		OldValue: changeValue(before),
+	}
+	if historyItem.User.ID != 0 {
+		change.Actor = &model.Actor{
+			ID:       strconv.Itoa(historyItem.User.ID),
+			Email:    historyItem.User.Email,
+			Username: historyItem.User.Username,
+		}
+	}
+	if changedAt, ok := historyItemTime(historyItem); ok {
+		change.ChangedAt = &changedAt
+	}
+
+	// the item contains the added or removed user only, so the values are the assignees of the task
+	if change.Kind == model.ChangeKindAssignee {
+		change.Value = task.Assignees
+		change.OldValue = previousAssignees(task.Assignees, historyItem.Field, before, after)
+	}
+
+	return change
+}
+
+func changeKind(field string) model.ChangeKind {
+	switch field {
+	case clickup.FieldName:
+		return model.ChangeKindName
+	case clickup.FieldContent:
+		return model.ChangeKindDescription
+	case clickup.FieldStatus:
+		return model.ChangeKindStatus
+	case clickup.FieldPriority:
+		return model.ChangeKindPriority
+	case clickup.FieldAssigneeAdd, clickup.FieldAssigneeRem:
+		return model.ChangeKindAssignee
+	case clickup.FieldDueDate:
+		return model.ChangeKindDueDate
+	case clickup.FieldStartDate:
+		return model.ChangeKindStartDate
+	case clickup.FieldTag, clickup.FieldTagRemoved:
+		return model.ChangeKindTags
+	case clickup.FieldSectionMoved:
+		return model.ChangeKindList
+	case clickup.FieldComment:
+		return model.ChangeKindComment
+	case clickup.FieldTimeEstimate:
+		return model.ChangeKindTimeEstimate
+	case clickup.FieldTimeSpent:
+		return model.ChangeKindTimeTracked
+	case clickup.FieldTaskCreation:
+		return model.ChangeKindCreated
+	default:
+		return model.ChangeKindOther
+	}
+}
+
+// previousAssignees restores the assignees of the task before the user was added or removed.
+func previousAssignees(assignees []clickup.User, field string, before, after interface{}) []clickup.User {
+	user, ok := after.(*clickup.User)
+	if !ok {
+		user, _ = before.(*clickup.User)
+	}
+
+	previous := make([]clickup.User, 0, len(assignees)+1)
+	for _, assignee := range assignees {
+		if user == nil || assignee.ID != user.ID {
+			previous = append(previous, assignee)
+		}
+	}
+	if field == clickup.FieldAssigneeRem && user != nil {
+		previous = append(previous, *user)
+	}
+
+	return previous
+}
+
+// unversionedValue is the new value as consumers of payloads without a version expect it.
+func unversionedValue(eventType clickup.EventType, historyItem clickup.HistoryItem, task *clickup.Task) interface{} {
+	switch eventType {
+	case clickup.TaskUpdated:
+		return historyItem.After
+	case clickup.TaskStatusUpdated:
+		if after, ok := historyItem.After.(map[string]interface{}); ok {
+			return after["status"]
+		}
+	case clickup.TaskPriorityUpdated:
+		if after, ok := historyItem.After.(map[string]interface{}); ok {
+			return after["priority"]
+		}
+	case clickup.TaskAssigneeUpdated:
+		return task.Assignees
+	}
+
+	return nil
+}
+
+// changeValue converts a decoded value of a history item to the value sent to BRP.
+func changeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *clickup.HistoryStatus:
+		return v.Status
+	case *clickup.HistoryPriority:
+		return v.Priority
+	case *time.Time:
+		return v.Format(time.RFC3339)
+	case []clickup.Tag:
+		names := make([]string, 0, len(v))
+		for _, tag := range v {
+			names = append(names, tag.Name)
+		}
+		return names
+	case *clickup.HistoryList:
+		return v
+	case *clickup.Comment:
+		return v.TextContent
+	case *time.Duration:
+		return v.Milliseconds()
+	case *clickup.TimeEntry:
+		if ms, err := strconv.ParseInt(v.Time, 10, 64); err == nil {
+			return ms
+		}
+		return nil
+	default:
+		return value
+	}
+}
+
+func isEventActual(taskDate, eventDate string) bool {
+	taskTimestamp, err := strconv.Atoi(taskDate)
+	if err != nil {
+		return true
+	}
+	eventTimestamp, err := strconv.Atoi(eventDate)
+	if err != nil {
+		return true
+	}
+
+	return taskTimestamp <= eventTimestamp
+}
@@ -0,0 +1,75 @@
+package consumer
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+)
+
+const (
+	defaultWebhookDedupTTL        = 72 * time.Hour
+	webhookHistoryCleanupInterval = time.Hour
+)
+
+func webhookDedupTTL(cfg *config.Config) time.Duration {
+	if cfg.Webhooks.DedupTTL > 0 {
+		return cfg.Webhooks.DedupTTL
+	}
+
+	return defaultWebhookDedupTTL
+}
+
+// isOverwriteField reports whether the last change of the field wins, so an older change must not be applied after a newer one.
+func isOverwriteField(field string) bool {
+	switch field {
+	case clickup.FieldStatus, clickup.FieldPriority, clickup.FieldName, clickup.FieldContent, clickup.FieldDueDate:
+		return true
+	default:
+		return false
+	}
+}
+
+// historyItemTime parses the date of the item, which is a Unix timestamp in milliseconds.
+func historyItemTime(item clickup.HistoryItem) (time.Time, bool) {
+	ms, err := strconv.ParseInt(item.Date, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC(), true
+}
+
+// WebhookHistoryCleaner deletes history items of webhooks, which ClickUp doesn't retry any more.
+type WebhookHistoryCleaner struct {
+	db  contract.Storage
+	ttl time.Duration
+}
+
+func NewWebhookHistoryCleaner(cfg *config.Config, db contract.Storage) *WebhookHistoryCleaner {
+	return &WebhookHistoryCleaner{
+		db:  db,
+		ttl: webhookDedupTTL(cfg),
+	}
+}
+
+func (c *WebhookHistoryCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookHistoryCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.db.DeleteWebhookHistoryItems(ctx, c.ttl); err != nil {
+				logrus.Errorf("webhook history cleaner: %s", err.Error())
+			}
+		}
+	}
+}
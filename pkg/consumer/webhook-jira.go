@@ -0,0 +1,171 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/jira"
+	"x-qdo/jiraclick/pkg/publisher"
+	"x-qdo/jiraclick/pkg/syncer"
+)
+
+// jiraChangelogPrefix keeps IDs of Jira changelogs apart from ClickUp history items they are claimed with.
+const jiraChangelogPrefix = "jira:"
+
+// JiraWebhookAction sends the changes of a webhook accepted by the HTTP handler to BRP and syncs them
+// to the linked ClickUp task. A changelog which is seen already is dropped, since Jira retries webhooks.
+type JiraWebhookAction struct {
+	publisher *publisher.EventPublisher
+	db        contract.Storage
+	syncer    *syncer.Syncer
+	dedupTTL  time.Duration
+}
+
+func NewJiraWebhookAction(
+	cfg *config.Config,
+	p *publisher.EventPublisher,
+	db contract.Storage,
+	syncer *syncer.Syncer,
+) (contract.Action, error) {
+	return &JiraWebhookAction{
+		publisher: p,
+		db:        db,
+		syncer:    syncer,
+		dedupTTL:  webhookDedupTTL(cfg),
+	}, nil
+}
+
+func (a *JiraWebhookAction) ProcessAction(ctx context.Context, delivery amqp.Delivery) error {
+	var webhook model.InboundWebhook
+
+	ctx, span := otel.Tracer("jira webhook").Start(ctx, "ProcessAction")
+	defer span.End()
+
+	if err := json.Unmarshal(delivery.Body, &webhook); err != nil {
+		// a broken message can't be fixed by a retry
+		span.RecordError(errors.Wrap(err, "Can't unmarshall webhook"))
+		return nil
+	}
+	span.SetAttributes(
+		attribute.String("tenant", webhook.Tenant),
+		attribute.String("source", webhook.Source),
+	)
+
+	event, err := jira.ParseEvent(ctx, webhook.Body)
+	if err != nil {
+		span.RecordError(errors.Wrap(err, "Jira webhook: body can't be parsed"))
+		return nil
+	} else if event == nil {
+		span.AddEvent("Jira webhook: webhook is without changes data")
+		return nil
+	}
+
+	return a.doAction(ctx, event, webhook.Tenant)
+}
+
+func (a *JiraWebhookAction) doAction(ctx context.Context, event *jira.WebhookEvent, tenant string) error {
+	ctx, span := otel.Tracer("jira webhook").Start(ctx, "doAction")
+	defer span.End()
+
+	changes := generateJiraTaskChangesByEvent(event)
+	slackChannel := tenant
+	link, err := a.db.GetTaskLinkByJiraID(ctx, event.Issue.ID)
+	if err != nil {
+		span.RecordError(errors.Wrap(err, "Jira webhook: can't get task link"))
+	} else if link != nil {
+		changes.ClickupID = link.ClickupID
+		slackChannel = link.SlackChannel
+	}
+
+	// the changelog is claimed in the transaction of the event, so a failed delivery doesn't mark it applied
+	if err = a.db.Begin(ctx); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	changelogID := ""
+	if event.Changelog.ID != "" {
+		changelogID = jiraChangelogPrefix + event.Changelog.ID
+		claimed, err := a.db.ClaimWebhookHistoryItem(ctx, &model.WebhookHistoryItem{
+			ID:     changelogID,
+			TaskID: event.Issue.ID,
+		}, a.dedupTTL)
+		if err != nil {
+			_ = a.db.Rollback(ctx)
+			err = errors.Wrap(err, "Jira webhook: can't check the changelog")
+			span.RecordError(err)
+			return err
+		}
+		if !claimed {
+			_ = a.db.Rollback(ctx)
+			span.AddEvent("duplicated changelog dropped", trace.WithAttributes(attribute.String("id", event.Changelog.ID)))
+			return nil
+		}
+	}
+
+	span.AddEvent("changes are defined")
+	err = a.publisher.JiraTaskChanged(ctx, changes, slackChannel)
+	if err != nil {
+		_ = a.db.Rollback(ctx)
+		err = errors.Wrap(err, "Jira webhook: can't trigger changes event")
+		span.RecordError(err)
+		return err
+	}
+
+	if err = a.db.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if link != nil && link.ClickupID != "" && event.Type == jira.IssueUpdated {
+		if err = a.syncer.SyncJiraChanges(ctx, link, event); err != nil {
+			err = errors.Wrap(err, "Jira webhook: can't sync changes to ClickUp")
+			span.RecordError(err)
+			// the changelog is processed again on the retry, BRP tolerates the repeated event as any outbox redelivery
+			if changelogID != "" {
+				if releaseErr := a.db.ReleaseWebhookHistoryItems(ctx, []string{changelogID}); releaseErr != nil {
+					span.RecordError(releaseErr)
+					logrus.Error(errors.Wrap(releaseErr, "Jira webhook: can't release the changelog"))
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func generateJiraTaskChangesByEvent(event *jira.WebhookEvent) model.TaskChanges {
+	changes := model.TaskChanges{
+		Type:     string(event.Type),
+		JiraID:   event.Issue.ID,
+		Username: event.User.DisplayName,
+	}
+
+	switch event.Type {
+	case jira.IssueUpdated:
+		for _, item := range event.Changelog.Items {
+			changes.AddChange(item.Field, item.ToString)
+		}
+	case jira.CommentCreated:
+		changes.AddChange("comment", event.Comment.Body)
+		if changes.Username == "" {
+			changes.Username = event.Comment.Author.DisplayName
+		}
+	case jira.IssueDeleted:
+		changes.AddChange("deleted", true)
+	}
+
+	return changes
+}
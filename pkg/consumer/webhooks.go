@@ -0,0 +1,45 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/contract"
+)
+
+// webhooksAction passes a webhook of the webhooks queue to the action of its source.
+type webhooksAction struct {
+	actions map[string]contract.Action
+}
+
+func newWebhooksAction(actions map[string]contract.Action) contract.Action {
+	return &webhooksAction{
+		actions: actions,
+	}
+}
+
+func (a *webhooksAction) ProcessAction(ctx context.Context, delivery amqp.Delivery) error {
+	var webhook struct {
+		Source string `json:"source"`
+	}
+
+	span := trace.SpanFromContext(ctx)
+
+	if err := json.Unmarshal(delivery.Body, &webhook); err != nil {
+		// a broken message can't be fixed by a retry
+		span.RecordError(errors.Wrap(err, "Can't unmarshall webhook"))
+		return nil
+	}
+
+	action, ok := a.actions[webhook.Source]
+	if !ok {
+		span.RecordError(errors.Errorf("webhooks of %q aren't processed", webhook.Source))
+		return nil
+	}
+
+	return action.ProcessAction(ctx, delivery)
+}
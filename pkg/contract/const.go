@@ -3,6 +3,9 @@ package contract
 const (
 	BRPActionsExchange = "actions"
 	BRPEventsExchange  = "events"
+	RetryExchange      = "actions.retry"
+	ParkingExchange    = "actions.parking"
+	WebhooksExchange   = "webhooks"
 )
 
 type RoutingKey string
@@ -13,7 +16,13 @@ const (
 	TaskUpdateClickUp RoutingKey = "task:update.clickup"
 	TaskUpdateJira    RoutingKey = "task:update.jira"
 
+	Webhooks RoutingKey = "webhooks"
+
 	TaskCreatedClickUpEvent RoutingKey = "t:%s:clickup:task.created"
 	TaskCreatedJiraEvent    RoutingKey = "t:%s:jira:task.created"
 	TaskUpdatedClickUpEvent RoutingKey = "t:%s:clickup:task.updated"
+	TaskUpdatedJiraEvent    RoutingKey = "t:%s:jira:task.updated"
+	TaskSyncFailedEvent     RoutingKey = "t:%s:task.sync_failed"
+	TaskFailedEvent         RoutingKey = "t:%s:task.failed"
+	UnknownTenantEvent      RoutingKey = "t:%s:unknown_tenant"
 )
@@ -2,6 +2,7 @@ package contract
 
 import (
 	"context"
+	"time"
 	"x-qdo/jiraclick/pkg/model"
 )
 
@@ -13,4 +14,56 @@ type Storage interface {
 
 	GetJiraAccounts(ctx context.Context) (map[string]model.JiraAccount, error)
 	GetClickUpAccounts(ctx context.Context) (map[string]model.ClickUpAccount, error)
+	// ListAccounts returns all accounts including disabled ones.
+	ListAccounts(ctx context.Context) ([]model.Account, error)
+	CreateAccount(ctx context.Context, account *model.Account) error
+	// DisableAccount sets delete_at of the account, disabled accounts aren't loaded.
+	DisableAccount(ctx context.Context, resource, slackChannel string) error
+	// UpdateAccountProps merges props into the jsonb props of the account.
+	UpdateAccountProps(ctx context.Context, resource, slackChannel string, props map[string]interface{}) error
+	// ReencryptAccounts encrypts secret props of all accounts with the active key.
+	ReencryptAccounts(ctx context.Context) (int, error)
+	// WatchAccounts signals on every change of the accounts till ctx is done.
+	WatchAccounts(ctx context.Context) <-chan struct{}
+
+	// CreateTaskLink stores the link or merges its non-empty IDs into the existing link of the same Slack thread.
+	CreateTaskLink(ctx context.Context, link *model.TaskLink) error
+	// GetTaskLinkBy* methods return nil without an error when the link is not found.
+	GetTaskLinkByClickUpID(ctx context.Context, clickupID string) (*model.TaskLink, error)
+	GetTaskLinkByJiraID(ctx context.Context, jiraID string) (*model.TaskLink, error)
+	GetTaskLinkBySlackThread(ctx context.Context, slackChannel, slackTS string) (*model.TaskLink, error)
+
+	GetStatusMappings(ctx context.Context, slackChannel string) (model.StatusMappings, error)
+	// UpsertStatusMapping stores the mapping or replaces the mapping of the same ClickUp status.
+	UpsertStatusMapping(ctx context.Context, mapping *model.StatusMapping) error
+	// DeleteStatusMapping reports false when there is no mapping of the ClickUp status.
+	DeleteStatusMapping(ctx context.Context, slackChannel, clickupStatus string) (bool, error)
+
+	CreateSyncMark(ctx context.Context, mark *model.SyncMark) error
+	// ConsumeSyncMark deletes a matching mark younger than ttl and reports whether it existed.
+	ConsumeSyncMark(ctx context.Context, mark *model.SyncMark, ttl time.Duration) (bool, error)
+
+	CreateProcessedMessage(ctx context.Context, message *model.ProcessedMessage) error
+	// GetProcessedMessage returns nil without an error when the message is not processed yet.
+	GetProcessedMessage(ctx context.Context, messageID string) (*model.ProcessedMessage, error)
+	// DeleteProcessedMessages deletes messages processed longer than ttl ago and returns their number.
+	DeleteProcessedMessages(ctx context.Context, ttl time.Duration) (int, error)
+
+	// ClaimWebhookHistoryItem stores the item and reports false when it's already seen within ttl.
+	ClaimWebhookHistoryItem(ctx context.Context, item *model.WebhookHistoryItem, ttl time.Duration) (bool, error)
+	// ReleaseWebhookHistoryItems deletes the claimed items, so they are processed again on a retry.
+	ReleaseWebhookHistoryItems(ctx context.Context, ids []string) error
+	// DeleteWebhookHistoryItems deletes items older than ttl and returns their number.
+	DeleteWebhookHistoryItems(ctx context.Context, ttl time.Duration) (int, error)
+	// ApplyTaskFieldChange stores the change and reports false when a newer change of the field is already applied.
+	ApplyTaskFieldChange(ctx context.Context, change *model.TaskFieldChange) (bool, error)
+
+	// CreateOutboxEvent stores the event in the transaction of ctx, if there is one.
+	CreateOutboxEvent(ctx context.Context, event *model.OutboxEvent) error
+	// ClaimPendingOutboxEvents locks pending events in the transaction of ctx, events locked by another
+	// transaction are skipped.
+	ClaimPendingOutboxEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	MarkOutboxEventSent(ctx context.Context, id int) error
+	// DeleteSentOutboxEvents deletes events sent longer than ttl ago and returns their number.
+	DeleteSentOutboxEvents(ctx context.Context, ttl time.Duration) (int, error)
 }
@@ -0,0 +1,215 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/provider/jira"
+)
+
+type Check struct {
+	Resource string `json:"resource"`
+	Tenant   string `json:"tenant,omitempty"`
+	Name     string `json:"check"`
+	Passed   bool   `json:"passed"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+type Report struct {
+	Passed bool    `json:"passed"`
+	Checks []Check `json:"checks"`
+}
+
+// Failed returns the number of failed checks.
+func (r *Report) Failed() int {
+	failed := 0
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+func (r *Report) pass(resource, tenant, name, detail string) {
+	r.Checks = append(r.Checks, Check{Resource: resource, Tenant: tenant, Name: name, Passed: true, Detail: detail})
+}
+
+func (r *Report) fail(resource, tenant, name string, err error) {
+	r.Checks = append(r.Checks, Check{Resource: resource, Tenant: tenant, Name: name, Detail: err.Error()})
+	r.Passed = false
+}
+
+// Run checks the connections and every account without failing on the first problem, so the report lists all of them.
+func Run(ctx context.Context) Report {
+	report := Report{Passed: true}
+
+	ctx, span := otel.Tracer("doctor").Start(ctx, "Run")
+	defer span.End()
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		report.fail("config", "", "load", err)
+		return report
+	}
+	report.pass("config", "", "load", "")
+
+	if conn, err := amqp.Dial(cfg.GetRabbitMQURL()); err != nil {
+		report.fail("rabbitmq", "", "connect", err)
+	} else {
+		_ = conn.Close()
+		report.pass("rabbitmq", "", "connect", cfg.RabbitMQ.Host)
+	}
+
+	db, err := provider.NewPostgres(cfg)
+	if err != nil {
+		report.fail("postgres", "", "connect", err)
+		return report
+	}
+	defer db.Close()
+	report.pass("postgres", "", "connect", "")
+
+	checkClickUpAccounts(ctx, db, &report)
+	checkJiraAccounts(ctx, db, &report)
+
+	return report
+}
+
+func checkClickUpAccounts(ctx context.Context, db contract.Storage, report *Report) {
+	accounts, err := db.GetClickUpAccounts(ctx)
+	if err != nil {
+		report.fail(model.ClickUpResource, "", "accounts", err)
+		return
+	}
+
+	tenants := make([]string, 0, len(accounts))
+	for tenant := range accounts {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	for _, tenant := range tenants {
+		checkClickUpAccount(ctx, tenant, accounts[tenant], report)
+	}
+}
+
+func checkClickUpAccount(ctx context.Context, tenant string, account model.ClickUpAccount, report *Report) {
+	client := clickup.NewAPIClient(tenant, account)
+
+	user, err := client.GetUser(ctx)
+	if err != nil {
+		report.fail(model.ClickUpResource, tenant, "user", err)
+		return
+	}
+	report.pass(model.ClickUpResource, tenant, "user", user.Email)
+
+	list, err := client.GetList(ctx)
+	if err != nil {
+		report.fail(model.ClickUpResource, tenant, "list", err)
+		return
+	}
+	report.pass(model.ClickUpResource, tenant, "list", list.Name)
+
+	if account.InitialTaskStatus == "" {
+		report.pass(model.ClickUpResource, tenant, "initial status", "not set, the list default is used")
+	} else if hasStatus(list, account.InitialTaskStatus) {
+		report.pass(model.ClickUpResource, tenant, "initial status", account.InitialTaskStatus)
+	} else {
+		report.fail(model.ClickUpResource, tenant, "initial status",
+			fmt.Errorf("status %q is not found in list %s", account.InitialTaskStatus, account.List))
+	}
+
+	fields, err := client.GetListFields(ctx)
+	if err != nil {
+		report.fail(model.ClickUpResource, tenant, "custom fields", err)
+		return
+	}
+	ids := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		ids[field.ID] = struct{}{}
+	}
+	var missing []string
+	for key, id := range account.CustomFields {
+		if _, ok := ids[id]; !ok {
+			missing = append(missing, fmt.Sprintf("%s (%s)", key, id))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		report.fail(model.ClickUpResource, tenant, "custom fields",
+			fmt.Errorf("fields are not found in list %s: %s", account.List, strings.Join(missing, ", ")))
+	} else {
+		report.pass(model.ClickUpResource, tenant, "custom fields", fmt.Sprintf("%d mapped", len(account.CustomFields)))
+	}
+}
+
+func hasStatus(list *clickup.List, status string) bool {
+	for _, s := range list.Statuses {
+		if strings.EqualFold(s.Status, status) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkJiraAccounts(ctx context.Context, db contract.Storage, report *Report) {
+	accounts, err := db.GetJiraAccounts(ctx)
+	if err != nil {
+		report.fail(model.JiraResource, "", "accounts", err)
+		return
+	}
+
+	tenants := make([]string, 0, len(accounts))
+	for tenant := range accounts {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	for _, tenant := range tenants {
+		checkJiraAccount(ctx, tenant, accounts[tenant], report)
+	}
+}
+
+func checkJiraAccount(ctx context.Context, tenant string, account model.JiraAccount, report *Report) {
+	client, err := jira.NewClient(tenant, account)
+	if err != nil {
+		report.fail(model.JiraResource, tenant, "client", err)
+		return
+	}
+
+	user, err := client.GetMyself(ctx)
+	if err != nil {
+		report.fail(model.JiraResource, tenant, "myself", err)
+		return
+	}
+	report.pass(model.JiraResource, tenant, "myself", user.EmailAddress)
+
+	project, err := client.GetProject(ctx)
+	if err != nil {
+		report.fail(model.JiraResource, tenant, "project", err)
+		return
+	}
+	report.pass(model.JiraResource, tenant, "project", project.Name)
+
+	fields := jira.RequiredFields(account)
+	for _, issueType := range jira.IssueTypes(account) {
+		name := fmt.Sprintf("issue type %q", issueType)
+		if err = client.CheckCreateMeta(ctx, issueType, fields); err != nil {
+			report.fail(model.JiraResource, tenant, name, err)
+		} else {
+			report.pass(model.JiraResource, tenant, name, fmt.Sprintf("%d fields", len(fields)))
+		}
+	}
+}
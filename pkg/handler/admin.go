@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+
+	"x-qdo/jiraclick/pkg/admin"
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/model"
+)
+
+type adminAccounts struct {
+	cfg      *config.Config
+	logger   *logrus.Logger
+	accounts *admin.AccountsService
+}
+
+func NewAdminAccountsHandler(
+	cfg *config.Config,
+	logger *logrus.Logger,
+	accounts *admin.AccountsService,
+) *adminAccounts {
+	return &adminAccounts{
+		cfg:      cfg,
+		logger:   logger,
+		accounts: accounts,
+	}
+}
+
+// Authorize accepts requests with `Authorization: Bearer <admin.token>`.
+func (h *adminAccounts) Authorize(ctx *gin.Context) {
+	auth := ctx.GetHeader("Authorization")
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if h.cfg.Admin.Token == "" || !strings.HasPrefix(auth, "Bearer ") ||
+		subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.Admin.Token)) != 1 {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token is not valid"})
+		return
+	}
+
+	ctx.Next()
+}
+
+func (h *adminAccounts) List(ctx *gin.Context) {
+	spanCtx, span := otel.Tracer("http handler").Start(ctx.Request.Context(), "ListAccounts")
+	defer span.End()
+
+	accounts, err := h.accounts.List(spanCtx, ctx.Query("resource"), ctx.Query("disabled") == "true")
+	if err != nil {
+		span.RecordError(err)
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
+
+func (h *adminAccounts) Get(ctx *gin.Context) {
+	spanCtx, span := otel.Tracer("http handler").Start(ctx.Request.Context(), "GetAccount")
+	defer span.End()
+
+	account, err := h.accounts.Get(spanCtx, ctx.Param("resource"), ctx.Param("channel"))
+	if err != nil {
+		span.RecordError(err)
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+func (h *adminAccounts) Add(ctx *gin.Context) {
+	var request admin.AccountRequest
+
+	spanCtx, span := otel.Tracer("http handler").Start(ctx.Request.Context(), "AddAccount")
+	defer span.End()
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.accounts.Add(spanCtx, request)
+	if err != nil {
+		span.RecordError(err)
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, account)
+}
+
+func (h *adminAccounts) Update(ctx *gin.Context) {
+	var props map[string]interface{}
+
+	spanCtx, span := otel.Tracer("http handler").Start(ctx.Request.Context(), "UpdateAccount")
+	defer span.End()
+
+	if err := ctx.ShouldBindJSON(&props); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.accounts.Update(spanCtx, ctx.Param("resource"), ctx.Param("channel"), props)
+	if err != nil {
+		span.RecordError(err)
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+func (h *adminAccounts) Disable(ctx *gin.Context) {
+	spanCtx, span := otel.Tracer("http handler").Start(ctx.Request.Context(), "DisableAccount")
+	defer span.End()
+
+	if err := h.accounts.Disable(spanCtx, ctx.Param("resource"), ctx.Param("channel")); err != nil {
+		span.RecordError(err)
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *adminAccounts) respondError(ctx *gin.Context, err error) {
+	var validationErr *model.AccountValidationError
+
+	switch {
+	case errors.As(err, &validationErr):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "account is not valid", "problems": validationErr.Problems})
+	case errors.Is(err, admin.ErrAccountNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, admin.ErrAccountExists):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -8,48 +8,64 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"net/http"
-	"strconv"
-	"x-qdo/jiraclick/pkg/contract"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/metrics"
 	"x-qdo/jiraclick/pkg/model"
 	"x-qdo/jiraclick/pkg/provider/clickup"
 	"x-qdo/jiraclick/pkg/publisher"
 )
 
+const clickUpSource = model.ClickUpResource
+
 type clickUpWebhooks struct {
-	cfg       *config.Config
-	logger    *logrus.Logger
-	publisher *publisher.EventPublisher
-	clickup   *clickup.ConnectorPool
-	db        contract.Storage
+	cfg      *config.Config
+	logger   *logrus.Logger
+	webhooks *publisher.WebhookPublisher
+	clickup  *clickup.ConnectorPool
 }
 
+// NewClickUpWebhooksHandler accepts webhooks with a valid signature and leaves their processing to the worker,
+// so slow ClickUp responses don't make ClickUp time out on our endpoint and disable the webhook.
 func NewClickUpWebhooksHandler(
 	cfg *config.Config,
 	logger *logrus.Logger,
 	queue *amqpwrapper.RabbitChannel,
 	clickup *clickup.ConnectorPool,
-	db contract.Storage,
 ) (*clickUpWebhooks, error) {
-	p, err := publisher.NewEventPublisher(queue)
+	p, err := publisher.NewWebhookPublisher(cfg, queue)
 	if err != nil {
 		return nil, err
 	}
 	return &clickUpWebhooks{
-		cfg:       cfg,
-		logger:    logger,
-		publisher: p,
-		clickup:   clickup,
-		db:        db,
+		cfg:      cfg,
+		logger:   logger,
+		webhooks: p,
+		clickup:  clickup,
 	}, nil
 }
 
+// TaskEvent serves the legacy route, which finds the tenant by checking the signature against secrets of every tenant.
 func (h *clickUpWebhooks) TaskEvent(ctx *gin.Context) {
+	h.acceptEvent(ctx, h.checkWebhookSecret)
+}
+
+// TenantTaskEvent checks the signature against secrets of the tenant of the route only.
+func (h *clickUpWebhooks) TenantTaskEvent(ctx *gin.Context) {
+	tenant := ctx.Param("tenant")
+	h.acceptEvent(ctx, func(spanCtx context.Context, signature, body string) (bool, string) {
+		return h.checkTenantWebhookSecret(spanCtx, tenant, signature, body)
+	})
+}
+
+type signatureCheck func(ctx context.Context, signature, body string) (bool, string)
+
+func (h *clickUpWebhooks) acceptEvent(ctx *gin.Context, checkSignature signatureCheck) {
 	spanCtx, span := otel.Tracer("http handler").Start(ctx.Request.Context(), "TaskEvent")
 	defer span.End()
 
@@ -63,8 +79,9 @@ func (h *clickUpWebhooks) TaskEvent(ctx *gin.Context) {
 	}
 
 	body := buf.String()
-	accessed, tenant := h.checkWebhookSecret(spanCtx, ctx.Request.Header.Get("X-Signature"), body)
+	accessed, tenant := checkSignature(spanCtx, ctx.Request.Header.Get("X-Signature"), body)
 	if !accessed {
+		metrics.WebhookSignatureFailed(clickUpSource)
 		err := errors.New("ClickUp webhook: signature is not valid")
 		span.RecordError(err)
 		h.logger.Error(err)
@@ -76,12 +93,15 @@ func (h *clickUpWebhooks) TaskEvent(ctx *gin.Context) {
 	event, err := clickup.ParseEvent(spanCtx, body)
 	h.logger.Debug("Clickup Parsed Event: ", event)
 	if err != nil {
+		// the signature is valid, so ClickUp would send the same body again and disable the webhook after all
+		metrics.WebhookEvent(clickUpSource, "unparsable")
 		err = errors.Wrap(err, "ClickUp webhook: body can't be parsed")
 		span.RecordError(err)
 		h.logger.Error(err)
-		ctx.Status(http.StatusInternalServerError)
+		ctx.Status(http.StatusOK)
 		return
 	} else if event == nil {
+		metrics.WebhookEvent(clickUpSource, "empty")
 		msg := "ClickUp webhook: webhook is without changes data"
 		span.AddEvent(msg)
 		h.logger.Debug(msg)
@@ -89,108 +109,60 @@ func (h *clickUpWebhooks) TaskEvent(ctx *gin.Context) {
 		return
 	}
 
-	err = h.doAction(spanCtx, event, tenant)
+	metrics.WebhookEvent(clickUpSource, string(event.Type))
+
+	err = h.webhooks.Publish(spanCtx, model.InboundWebhook{
+		Source:     clickUpSource,
+		Tenant:     tenant,
+		Body:       body,
+		ReceivedAt: time.Now(),
+	})
 	if err != nil {
+		err = errors.Wrap(err, "ClickUp webhook: can't be enqueued")
 		span.RecordError(err)
 		h.logger.Error(err)
 		ctx.Status(http.StatusInternalServerError)
 		return
 	}
+	span.AddEvent("webhook enqueued")
 
 	ctx.Status(http.StatusOK)
 }
 
-func (h *clickUpWebhooks) doAction(ctx context.Context, event *clickup.WebhookEvent, tenant string) error {
-	var changes model.TaskChanges
+func (h *clickUpWebhooks) checkWebhookSecret(ctx context.Context, signature, body string) (bool, string) {
 	span := trace.SpanFromContext(ctx)
-
-	task, err := h.clickup.GetInstance(tenant).GetTask(ctx, event.TaskID)
-	if err != nil {
-		err = errors.Wrap(err, "ClickUp webhook: can't get task")
-		span.RecordError(err)
-		return err
-	}
-	span.AddEvent("task retrieved from Clickup")
-
-	if event.Type == clickup.TaskUpdated && !isEventActual(task.DateUpdated, event.Changes[0].Date) {
-		span.AddEvent("task doesn't have `taskUpdated` status or update is not actual")
-		return nil
+	for _, tenant := range h.clickup.AllWebhookSecrets() {
+		if checkSecrets(ctx, signature, body, tenant.Secrets) {
+			span.AddEvent("signature checked", trace.WithAttributes(attribute.Bool("valid", true)))
+			return true, tenant.Tenant
+		}
 	}
+	span.AddEvent("signature checked", trace.WithAttributes(attribute.Bool("valid", false)))
+	return false, ""
+}
 
-	slackChannel := task.GetSlackChannel()
-	if slackChannel == "" {
-		err = errors.Wrap(err, "ClickUp webhook: slackChannel is not defined")
-		span.RecordError(err)
-		return err
-	}
-	span.AddEvent("slackChannel retrieved from task")
+func (h *clickUpWebhooks) checkTenantWebhookSecret(ctx context.Context, tenant, signature, body string) (bool, string) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("tenant", tenant))
 
-	changes = generateTaskChangesByEvent(event, task)
-	span.AddEvent("changes are defined")
-	err = h.publisher.ClickUpTaskUpdated(ctx, changes, slackChannel)
+	tenant, secrets, err := h.clickup.WebhookSecrets(tenant)
 	if err != nil {
-		err = errors.Wrap(err, "ClickUp webhook: can't trigger changes event")
 		span.RecordError(err)
-		return err
+		return false, ""
 	}
 
-	return nil
-}
-
-func generateTaskChangesByEvent(event *clickup.WebhookEvent, task *clickup.Task) model.TaskChanges {
-	changes := model.TaskChanges{
-		Type:      string(event.Type),
-		ClickupID: event.TaskID,
-	}
-	for _, historyItem := range event.Changes {
-		var value interface{}
-		switch event.Type {
-		case clickup.TaskUpdated:
-			value = historyItem.After
-		case clickup.TaskStatusUpdated:
-			if after, ok := historyItem.After.(map[string]interface{}); ok {
-				value = after["status"]
-			}
-		case clickup.TaskPriorityUpdated:
-			if after, ok := historyItem.After.(map[string]interface{}); ok {
-				value = after["priority"]
-			}
-		case clickup.TaskAssigneeUpdated:
-			value = task.Assignees
-		}
-		changes.AddChange(historyItem.Field, value)
-		changes.Username = historyItem.User.Username
-	}
-
-	return changes
-}
-
-func isEventActual(taskDate, eventDate string) bool {
-	taskTimestamp, err := strconv.Atoi(taskDate)
-	if err != nil {
-		return true
-	}
-	eventTimestamp, err := strconv.Atoi(eventDate)
-	if err != nil {
-		return true
-	}
+	valid := checkSecrets(ctx, signature, body, secrets)
+	span.AddEvent("signature checked", trace.WithAttributes(attribute.Bool("valid", valid)))
 
-	return taskTimestamp <= eventTimestamp
+	return valid, tenant
 }
 
-func (h *clickUpWebhooks) checkWebhookSecret(ctx context.Context, signature, body string) (bool, string) {
-	span := trace.SpanFromContext(ctx)
-	clickUpAccounts, err := h.db.GetClickUpAccounts(ctx)
-	if err != nil {
-		span.RecordError(err)
-		panic(err)
-	}
-	for tenant, acc := range clickUpAccounts {
-		if clickup.CheckSignature(ctx, signature, body, acc.WebhookSecret) {
-			span.AddEvent("signature checked", trace.WithAttributes(attribute.Bool("valid", true)))
-			return true, tenant
+func checkSecrets(ctx context.Context, signature, body string, secrets []string) bool {
+	for _, secret := range secrets {
+		if clickup.CheckSignature(ctx, signature, body, secret) {
+			return true
 		}
 	}
-	span.AddEvent("signature checked", trace.WithAttributes(attribute.Bool("valid", false)))
-	return false, ""
+
+	return false
 }
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/astreter/amqpwrapper/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/metrics"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/jira"
+	"x-qdo/jiraclick/pkg/publisher"
+)
+
+const jiraSource = model.JiraResource
+
+type jiraWebhooks struct {
+	cfg      *config.Config
+	logger   *logrus.Logger
+	webhooks *publisher.WebhookPublisher
+	jira     *jira.ConnectorPool
+}
+
+// NewJiraWebhooksHandler accepts webhooks with a valid signature and leaves their processing to the worker,
+// which retries them the way it retries ClickUp webhooks.
+func NewJiraWebhooksHandler(
+	cfg *config.Config,
+	logger *logrus.Logger,
+	queue *amqpwrapper.RabbitChannel,
+	jira *jira.ConnectorPool,
+) (*jiraWebhooks, error) {
+	p, err := publisher.NewWebhookPublisher(cfg, queue)
+	if err != nil {
+		return nil, err
+	}
+	return &jiraWebhooks{
+		cfg:      cfg,
+		logger:   logger,
+		webhooks: p,
+		jira:     jira,
+	}, nil
+}
+
+// TaskEvent is the legacy route, which checks the signature against secrets of all tenants.
+func (h *jiraWebhooks) TaskEvent(ctx *gin.Context) {
+	h.acceptEvent(ctx, h.checkWebhookSecret)
+}
+
+// TenantTaskEvent checks the signature against the secret of the tenant of the route only.
+func (h *jiraWebhooks) TenantTaskEvent(ctx *gin.Context) {
+	tenant := ctx.Param("tenant")
+	h.acceptEvent(ctx, func(spanCtx context.Context, r *http.Request, body string) (bool, string) {
+		return h.checkTenantWebhookSecret(spanCtx, tenant, r, body)
+	})
+}
+
+type jiraSignatureCheck func(ctx context.Context, r *http.Request, body string) (bool, string)
+
+func (h *jiraWebhooks) acceptEvent(ctx *gin.Context, checkSignature jiraSignatureCheck) {
+	spanCtx, span := otel.Tracer("http handler").Start(ctx.Request.Context(), "JiraTaskEvent")
+	defer span.End()
+
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(ctx.Request.Body); err != nil {
+		err = errors.Wrap(err, "Jira webhook: body can't be read")
+		span.RecordError(err)
+		h.logger.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	body := buf.String()
+	accessed, tenant := checkSignature(spanCtx, ctx.Request, body)
+	if !accessed {
+		metrics.WebhookSignatureFailed(jiraSource)
+		err := errors.New("Jira webhook: signature is not valid")
+		span.RecordError(err)
+		h.logger.Error(err)
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	h.logger.Debug("Jira Raw Event: ", body)
+	event, err := jira.ParseEvent(spanCtx, body)
+	h.logger.Debug("Jira Parsed Event: ", event)
+	if err != nil {
+		metrics.WebhookEvent(jiraSource, "unparsable")
+		err = errors.Wrap(err, "Jira webhook: body can't be parsed")
+		span.RecordError(err)
+		h.logger.Error(err)
+		ctx.Status(http.StatusBadRequest)
+		return
+	} else if event == nil {
+		metrics.WebhookEvent(jiraSource, "empty")
+		msg := "Jira webhook: webhook is without changes data"
+		span.AddEvent(msg)
+		h.logger.Debug(msg)
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	metrics.WebhookEvent(jiraSource, string(event.Type))
+
+	err = h.webhooks.Publish(spanCtx, model.InboundWebhook{
+		Source:     jiraSource,
+		Tenant:     tenant,
+		Body:       body,
+		ReceivedAt: time.Now(),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "Jira webhook: can't be enqueued")
+		span.RecordError(err)
+		h.logger.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	span.AddEvent("webhook enqueued")
+
+	ctx.Status(http.StatusOK)
+}
+
+// checkWebhookSecret checks tenants in their order, so a secret shared by tenants always resolves to the same one.
+func (h *jiraWebhooks) checkWebhookSecret(ctx context.Context, r *http.Request, body string) (bool, string) {
+	span := trace.SpanFromContext(ctx)
+
+	accounts := h.jira.GetAccounts()
+	tenants := make([]string, 0, len(accounts))
+	for tenant := range accounts {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	for _, tenant := range tenants {
+		if checkJiraSecret(ctx, r, body, accounts[tenant].WebhookSecret) {
+			span.AddEvent("signature checked", trace.WithAttributes(attribute.Bool("valid", true)))
+			return true, tenant
+		}
+	}
+	span.AddEvent("signature checked", trace.WithAttributes(attribute.Bool("valid", false)))
+	return false, ""
+}
+
+func (h *jiraWebhooks) checkTenantWebhookSecret(ctx context.Context, tenant string, r *http.Request, body string) (bool, string) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("tenant", tenant))
+
+	tenant, secret, err := h.jira.WebhookSecret(tenant)
+	if err != nil {
+		span.RecordError(err)
+		return false, ""
+	}
+
+	valid := checkJiraSecret(ctx, r, body, secret)
+	span.AddEvent("signature checked", trace.WithAttributes(attribute.Bool("valid", valid)))
+
+	return valid, tenant
+}
+
+func checkJiraSecret(ctx context.Context, r *http.Request, body, secret string) bool {
+	token := r.URL.Query().Get("jwt")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "JWT ") {
+		token = strings.TrimPrefix(auth, "JWT ")
+	}
+
+	return jira.CheckSignature(ctx, r.Header.Get("X-Hub-Signature"), body, secret) ||
+		jira.CheckJWT(ctx, token, secret, r.Method, r.URL.Path, r.URL.Query())
+}
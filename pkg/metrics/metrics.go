@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "jiraclick"
+
+// Outcomes of lookups of unknown tenants.
+const (
+	TenantFallback = "fallback"
+	TenantRejected = "rejected"
+)
+
+// Outcomes of processed actions.
+const (
+	ActionSucceeded = "succeeded"
+	ActionRetried   = "retried"
+	ActionDead      = "dead"
+	ActionParked    = "parked"
+	ActionFailed    = "failed"
+)
+
+var (
+	actionsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_processed_total",
+		Help:      "Actions processed by routing key and outcome.",
+	}, []string{"routing_key", "outcome"})
+
+	actionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "action_duration_seconds",
+		Help:      "Duration of action processing by routing key.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"routing_key"})
+
+	providerRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_requests_total",
+		Help:      "Requests to ClickUp and Jira APIs by tenant and response status.",
+	}, []string{"provider", "tenant", "status"})
+
+	providerLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "provider_request_duration_seconds",
+		Help:      "Latency of requests to ClickUp and Jira APIs by tenant.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "tenant"})
+
+	webhookEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_events_total",
+		Help:      "Received webhook events by source and event type.",
+	}, []string{"source", "type"})
+
+	webhookSignatureFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_signature_failures_total",
+		Help:      "Webhooks rejected because of an invalid signature by source.",
+	}, []string{"source"})
+
+	unknownTenantLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unknown_tenant_lookups_total",
+		Help:      "Lookups of tenants without an account or alias, served by the default account or rejected.",
+	}, []string{"resource", "outcome"})
+
+	accountsSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "accounts_skipped_total",
+		Help:      "Accounts which are skipped on load, because their props can't be decrypted or decoded, by resource.",
+	}, []string{"resource"})
+
+	publishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "publish_failures_total",
+		Help:      "Events which failed to be published by event.",
+	}, []string{"event"})
+)
+
+// Serve exposes /metrics on the port, metrics aren't served when the port is empty.
+func Serve(port string) error {
+	if port == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return http.ListenAndServe(":"+port, mux)
+}
+
+func ActionProcessed(routingKey, outcome string, started time.Time) {
+	actionsProcessed.WithLabelValues(routingKey, outcome).Inc()
+	actionDuration.WithLabelValues(routingKey).Observe(time.Since(started).Seconds())
+}
+
+func WebhookEvent(source, eventType string) {
+	webhookEvents.WithLabelValues(source, eventType).Inc()
+}
+
+func WebhookSignatureFailed(source string) {
+	webhookSignatureFailures.WithLabelValues(source).Inc()
+}
+
+func UnknownTenantLookup(resource, outcome string) {
+	unknownTenantLookups.WithLabelValues(resource, outcome).Inc()
+}
+
+func AccountSkipped(resource string) {
+	accountsSkipped.WithLabelValues(resource).Inc()
+}
+
+// PublishFailed counts a failure by the event name, the tenant prefix `t:<tenant>:` is dropped from the routing key.
+func PublishFailed(routingKey string) {
+	event := routingKey
+	if parts := strings.SplitN(routingKey, ":", 3); len(parts) == 3 && parts[0] == "t" {
+		event = parts[2]
+	}
+
+	publishFailures.WithLabelValues(event).Inc()
+}
+
+type transport struct {
+	provider string
+	tenant   string
+	next     http.RoundTripper
+}
+
+// NewTransport measures latency and response statuses of the tenant's requests to the provider API.
+func NewTransport(provider, tenant string, next http.RoundTripper) http.RoundTripper {
+	return &transport{
+		provider: provider,
+		tenant:   tenant,
+		next:     next,
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+
+	r, err := t.next.RoundTrip(req)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(r.StatusCode)
+	}
+	providerRequests.WithLabelValues(t.provider, t.tenant, status).Inc()
+	providerLatency.WithLabelValues(t.provider, t.tenant).Observe(time.Since(started).Seconds())
+
+	return r, err
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaskedSecret replaces secret props in responses; it is ignored when it comes back in an update.
+const MaskedSecret = "********"
+
+// AccountValidationError lists problems of account props, so they can be fixed at once.
+type AccountValidationError struct {
+	Problems []string
+}
+
+func (e *AccountValidationError) Error() string {
+	return "account is not valid: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateAccountProps decodes props into the typed account of the resource, rejecting unknown props, and validates it.
+func ValidateAccountProps(resource string, props map[string]interface{}) error {
+	var account interface{ problems() []string }
+
+	switch resource {
+	case ClickUpResource:
+		account = new(ClickUpAccount)
+	case JiraResource:
+		account = new(JiraAccount)
+	default:
+		return &AccountValidationError{Problems: []string{fmt.Sprintf("resource %q is not supported", resource)}}
+	}
+
+	b, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(account); err != nil {
+		return &AccountValidationError{Problems: []string{err.Error()}}
+	}
+
+	if problems := account.problems(); len(problems) > 0 {
+		return &AccountValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+func (a *ClickUpAccount) problems() []string {
+	var problems []string
+
+	problems = append(problems, checkURL("host", a.Host)...)
+	if a.Token == "" {
+		problems = append(problems, "token is required")
+	}
+	if a.List == "" {
+		problems = append(problems, "list is required")
+	}
+	if a.RequestsPerMinute < 0 {
+		problems = append(problems, "requests_per_minute must not be negative")
+	}
+	for key, id := range a.CustomFields {
+		if id == "" {
+			problems = append(problems, fmt.Sprintf("custom_fields.%s must not be empty", key))
+		}
+	}
+	for i, secret := range a.WebhookSecrets {
+		if secret == "" {
+			problems = append(problems, fmt.Sprintf("webhooksecrets[%d] must not be empty", i))
+		}
+	}
+
+	return problems
+}
+
+func (a *JiraAccount) problems() []string {
+	var problems []string
+
+	if a.Username == "" {
+		problems = append(problems, "username is required")
+	}
+	if a.APIToken == "" {
+		problems = append(problems, "apitoken is required")
+	}
+	problems = append(problems, checkURL("baseurl", a.BaseURL)...)
+	if a.Project == "" {
+		problems = append(problems, "project is required")
+	}
+	for field, direction := range a.Sync {
+		switch direction {
+		case SyncBoth, SyncToJira, SyncToClickUp, SyncDisabled:
+		default:
+			problems = append(problems, fmt.Sprintf("sync.%s: direction %q is not supported", field, direction))
+		}
+	}
+	for t := range a.IssueTypes {
+		if taskType(t) != RegularTaskType && taskType(t) != IncidentTaskType {
+			problems = append(problems, fmt.Sprintf("issue_types: task type %q is not supported", t))
+		}
+	}
+
+	return problems
+}
+
+func checkURL(name, value string) []string {
+	if value == "" {
+		return []string{name + " is required"}
+	}
+	if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+		return []string{name + " must be an absolute URL"}
+	}
+
+	return nil
+}
+
+// MaskSecrets returns a copy of props where secret props of the resource are masked.
+func MaskSecrets(resource string, props map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(props))
+	for key, value := range props {
+		masked[key] = value
+	}
+
+	for _, key := range SecretProps[resource] {
+		switch value := masked[key].(type) {
+		case string:
+			if value != "" {
+				masked[key] = MaskedSecret
+			}
+		case []interface{}:
+			values := make([]interface{}, len(value))
+			for i := range value {
+				values[i] = MaskedSecret
+			}
+			masked[key] = values
+		}
+	}
+
+	return masked
+}
+
+// UnmaskSecret replaces masked parts of a secret prop, which came back from MaskSecrets, with the stored value.
+// Items of a list are masked in place, so a masked item is restored by its position and new items are kept.
+func UnmaskSecret(value, stored interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if v == MaskedSecret {
+			return stored
+		}
+	case []interface{}:
+		storedItems, _ := stored.([]interface{})
+		items := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			if item != MaskedSecret {
+				items = append(items, item)
+			} else if i < len(storedItems) {
+				items = append(items, storedItems[i])
+			}
+		}
+		return items
+	}
+
+	return value
+}
@@ -1,24 +1,97 @@
 package model
 
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultJiraIssueType = "Story"
+
+	ClickUpResource = "clickup"
+	JiraResource    = "jira"
+)
+
+// SecretProps are account props which are stored encrypted.
+var SecretProps = map[string][]string{
+	ClickUpResource: {"token", "webhooksecret", "webhooksecrets"},
+	JiraResource:    {"apitoken", "webhooksecret"},
+}
+
 type Account struct {
 	tableName    struct{}    `pg:"accounts"`
 	Id           string      `pg:"type:serial"`
 	SlackChannel string      `pg:"slack_channel"`
 	Resource     string      `pg:"resource"`
 	Props        interface{} `pg:"props,type:jsonb"`
+	CreateAt     time.Time   `pg:"create_at,default:now()"`
+	UpdateAt     *time.Time  `pg:"update_at"`
+	DeleteAt     *time.Time  `pg:"delete_at"`
 }
 
+// GetProps returns props as a map, which is how jsonb props are decoded.
+func (a *Account) GetProps() map[string]interface{} {
+	if props, ok := a.Props.(map[string]interface{}); ok {
+		return props
+	}
+
+	return make(map[string]interface{})
+}
+
+// ClickUpAccount accepts webhooks signed by WebhookSecret or any of WebhookSecrets, which keep previous
+// secrets active while webhooks are rotated.
 type ClickUpAccount struct {
-	Host              string `json:"host"`
-	Token             string `json:"token"`
-	List              string `json:"list"`
-	WebhookSecret     string `json:"webhooksecret"`
-	InitialTaskStatus string `json:"initial_status"`
+	Host              string            `json:"host"`
+	Token             string            `json:"token"`
+	List              string            `json:"list"`
+	Team              string            `json:"team"`
+	WebhookSecret     string            `json:"webhooksecret"`
+	WebhookSecrets    []string          `json:"webhooksecrets"`
+	InitialTaskStatus string            `json:"initial_status"`
+	CustomFields      map[string]string `json:"custom_fields"`
+	RequestsPerMinute int               `json:"requests_per_minute"`
+	Aliases           []string          `json:"aliases"`
+	Default           bool              `json:"default"`
 }
 
 type JiraAccount struct {
-	Username string `json:"username"`
-	APIToken string `json:"apitoken"`
-	BaseURL  string `json:"baseurl"`
-	Project  string `json:"project"`
+	Username      string                 `json:"username"`
+	APIToken      string                 `json:"apitoken"`
+	BaseURL       string                 `json:"baseurl"`
+	Project       string                 `json:"project"`
+	WebhookSecret string                 `json:"webhooksecret"`
+	Sync          SyncSettings           `json:"sync"`
+	IssueTypes    map[string]string      `json:"issue_types"`
+	CustomFields  map[string]interface{} `json:"custom_fields"`
+	Components    []string               `json:"components"`
+	Labels        []string               `json:"labels"`
+	Priority      string                 `json:"priority"`
+	Aliases       []string               `json:"aliases"`
+	Default       bool                   `json:"default"`
+}
+
+// GetIssueType returns the Jira issue type configured for the task type.
+func (a JiraAccount) GetIssueType(t taskType) string {
+	if issueType, ok := a.IssueTypes[string(t)]; ok && issueType != "" {
+		return issueType
+	}
+
+	return DefaultJiraIssueType
+}
+
+// UnknownTenantError is returned when neither an account, nor an alias, nor a default account matches the tenant.
+type UnknownTenantError struct {
+	Resource string
+	Tenant   string
+}
+
+func (e *UnknownTenantError) Error() string {
+	return fmt.Sprintf("%s account for tenant %s is not found", e.Resource, e.Tenant)
+}
+
+// UnknownTenant is sent to BRP when a message of an unknown tenant is received.
+type UnknownTenant struct {
+	Resource string `json:"resource"`
+	Tenant   string `json:"tenant"`
+	Action   string `json:"action"`
 }
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// InboundWebhook is a webhook with a verified signature, which is accepted by the HTTP handler and processed
+// by the worker. The body is kept as it's received.
+type InboundWebhook struct {
+	Source     string    `json:"source"`
+	Tenant     string    `json:"tenant"`
+	Body       string    `json:"body"`
+	ReceivedAt time.Time `json:"received_at"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent is an event stored together with the state it describes and relayed to RabbitMQ afterwards.
+// TraceHeaders keep the trace context of the transaction, so the relayed event continues its trace;
+// Headers are sent as message headers, e.g. the retry count of a rescheduled delivery.
+type OutboxEvent struct {
+	tableName    struct{}          `pg:"outbox_events"`
+	Id           int               `pg:"id,pk"`
+	Exchange     string            `pg:"exchange"`
+	RoutingKey   string            `pg:"routing_key"`
+	Payload      json.RawMessage   `pg:"payload,type:jsonb"`
+	TraceHeaders map[string]string `pg:"trace_headers,type:jsonb"`
+	Headers      map[string]string `pg:"headers,type:jsonb"`
+	CreateAt     time.Time         `pg:"create_at,default:now()"`
+	SentAt       *time.Time        `pg:"sent_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// ProcessedMessage is the result of the BRP action message; a redelivered message with a result is acknowledged
+// without being processed again.
+type ProcessedMessage struct {
+	tableName struct{}     `pg:"processed_messages"`
+	MessageID string       `pg:"message_id,pk"`
+	Action    string       `pg:"action"`
+	Result    *TaskPayload `pg:"result,type:jsonb"`
+	CreateAt  time.Time    `pg:"create_at,default:now()"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// StatusMapping binds a ClickUp status to a Jira status. JiraTransition is the name or ID of the
+// transition to execute; when it is empty, any transition leading to JiraStatus is used.
+// Mappings are used only when sync.status of the Jira account is set.
+type StatusMapping struct {
+	tableName      struct{}   `pg:"status_mappings"`
+	Id             int        `pg:"id,pk"`
+	SlackChannel   string     `pg:"slack_channel"`
+	ClickUpStatus  string     `pg:"clickup_status"`
+	JiraStatus     string     `pg:"jira_status"`
+	JiraTransition string     `pg:"jira_transition"`
+	CreateAt       time.Time  `pg:"create_at,default:now()"`
+	UpdateAt       *time.Time `pg:"update_at"`
+}
+
+type StatusMappings []StatusMapping
+
+func (m StatusMappings) ByClickUpStatus(status string) *StatusMapping {
+	for i := range m {
+		if strings.EqualFold(m[i].ClickUpStatus, status) {
+			return &m[i]
+		}
+	}
+
+	return nil
+}
+
+func (m StatusMappings) ByJiraStatus(status string) *StatusMapping {
+	for i := range m {
+		if strings.EqualFold(m[i].JiraStatus, status) {
+			return &m[i]
+		}
+	}
+
+	return nil
+}
+
+// TaskSyncFailure describes a change which could not be synced to the counterpart task.
+type TaskSyncFailure struct {
+	ClickupID string     `json:"clickup_id,omitempty"`
+	JiraID    string     `json:"jira_id,omitempty"`
+	Origin    SyncOrigin `json:"origin"`
+	Field     SyncField  `json:"field"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+type SyncField string
+
+const (
+	SyncFieldName        SyncField = "name"
+	SyncFieldDescription SyncField = "description"
+	SyncFieldStatus      SyncField = "status"
+	SyncFieldPriority    SyncField = "priority"
+	SyncFieldAssignee    SyncField = "assignee"
+	SyncFieldDueDate     SyncField = "due_date"
+)
+
+type SyncDirection string
+
+const (
+	SyncBoth      SyncDirection = "both"
+	SyncToJira    SyncDirection = "to_jira"
+	SyncToClickUp SyncDirection = "to_clickup"
+	SyncDisabled  SyncDirection = "none"
+)
+
+type SyncOrigin string
+
+const (
+	SyncOriginClickUp SyncOrigin = "clickup"
+	SyncOriginJira    SyncOrigin = "jira"
+)
+
+// SyncSettings holds a direction per field; fields which are not listed are not synced.
+type SyncSettings map[SyncField]SyncDirection
+
+func (s SyncSettings) ToJira(field SyncField) bool {
+	return s[field] == SyncBoth || s[field] == SyncToJira
+}
+
+func (s SyncSettings) ToClickUp(field SyncField) bool {
+	return s[field] == SyncBoth || s[field] == SyncToClickUp
+}
+
+// SyncMark remembers a value written by the syncer, so the webhook it triggers on the Target side is not synced back.
+type SyncMark struct {
+	tableName struct{}   `pg:"sync_marks"`
+	Id        int        `pg:"id,pk"`
+	LinkID    int        `pg:"link_id"`
+	Target    SyncOrigin `pg:"target"`
+	Field     SyncField  `pg:"field"`
+	ValueHash string     `pg:"value_hash"`
+	CreateAt  time.Time  `pg:"create_at,default:now()"`
+}
+
+func NewSyncMark(link *TaskLink, target SyncOrigin, field SyncField, value string) *SyncMark {
+	hash := sha256.Sum256([]byte(strings.TrimSpace(strings.ToLower(value))))
+
+	return &SyncMark{
+		LinkID:    link.Id,
+		Target:    target,
+		Field:     field,
+		ValueHash: hex.EncodeToString(hash[:]),
+	}
+}
@@ -1,6 +1,33 @@
 package model
 
+import "time"
+
+// TaskChangesVersion is the version of TaskChanges with normalized values, actors, timestamps and kinds of changes.
+// Payloads without a version carry the field and the new value of a change only.
+const TaskChangesVersion = 2
+
+// ChangeKind is the normalized field of a change, which doesn't depend on the provider.
+type ChangeKind string
+
+const (
+	ChangeKindName         ChangeKind = "name"
+	ChangeKindDescription  ChangeKind = "description"
+	ChangeKindStatus       ChangeKind = "status"
+	ChangeKindPriority     ChangeKind = "priority"
+	ChangeKindAssignee     ChangeKind = "assignee"
+	ChangeKindDueDate      ChangeKind = "due_date"
+	ChangeKindStartDate    ChangeKind = "start_date"
+	ChangeKindTags         ChangeKind = "tags"
+	ChangeKindList         ChangeKind = "list"
+	ChangeKindComment      ChangeKind = "comment"
+	ChangeKindTimeEstimate ChangeKind = "time_estimate"
+	ChangeKindTimeTracked  ChangeKind = "time_tracked"
+	ChangeKindCreated      ChangeKind = "created"
+	ChangeKindOther        ChangeKind = "other"
+)
+
 type TaskChanges struct {
+	Version   int      `json:"version,omitempty"`
 	Type      string   `json:"type"`
 	ClickupID string   `json:"clickup_id,omitempty"`
 	JiraID    string   `json:"jira_id,omitempty"`
@@ -8,9 +35,22 @@ type TaskChanges struct {
 	Username  string   `json:"username"`
 }
 
+// Change keeps NewValue as it was sent before versioning; Value and OldValue are the normalized new and old values.
 type Change struct {
-	Field    string      `json:"field"`
-	NewValue interface{} `json:"new_value"`
+	Field     string      `json:"field"`
+	NewValue  interface{} `json:"new_value"`
+	Kind      ChangeKind  `json:"kind,omitempty"`
+	Value     interface{} `json:"value,omitempty"`
+	OldValue  interface{} `json:"old_value,omitempty"`
+	Actor     *Actor      `json:"actor,omitempty"`
+	ChangedAt *time.Time  `json:"changed_at,omitempty"`
+}
+
+// Actor is the user who made a change.
+type Actor struct {
+	ID       string `json:"id"`
+	Email    string `json:"email,omitempty"`
+	Username string `json:"username,omitempty"`
 }
 
 func (t *TaskChanges) AddChange(field string, value interface{}) {
@@ -0,0 +1,10 @@
+package model
+
+// TaskFailure is sent when an action is dead-lettered after all retry attempts.
+type TaskFailure struct {
+	Action    string       `json:"action"`
+	MessageID string       `json:"message_id"`
+	Attempts  int          `json:"attempts"`
+	Error     string       `json:"error"`
+	Payload   *TaskPayload `json:"payload,omitempty"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+type TaskLink struct {
+	tableName    struct{}   `pg:"task_links"`
+	Id           int        `pg:"id,pk"`
+	SlackChannel string     `pg:"slack_channel"`
+	SlackTS      string     `pg:"slack_ts"` // empty is stored as NULL, links without a thread are merged by RequestID
+	RequestID    string     `pg:"request_id"`
+	ClickupID    string     `pg:"clickup_id"`
+	JiraID       string     `pg:"jira_id"`
+	CreateAt     time.Time  `pg:"create_at,default:now()"`
+	UpdateAt     *time.Time `pg:"update_at"`
+}
+
+func NewTaskLink(payload TaskPayload) *TaskLink {
+	return &TaskLink{
+		SlackChannel: payload.SlackChannel,
+		SlackTS:      payload.SlackTS,
+		RequestID:    payload.ID,
+		ClickupID:    payload.ClickupID,
+		JiraID:       payload.JiraID,
+	}
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// WebhookHistoryItem is a history item of a ClickUp webhook or a Jira changelog, which is already applied; both retry
+// webhooks, so the same item may come again.
+type WebhookHistoryItem struct {
+	tableName struct{}  `pg:"webhook_history_items"`
+	ID        string    `pg:"id,pk"`
+	TaskID    string    `pg:"task_id"`
+	CreateAt  time.Time `pg:"create_at,default:now()"`
+}
+
+// TaskFieldChange is the latest applied change of the task field, an older change coming out of order
+// must not overwrite it.
+type TaskFieldChange struct {
+	tableName     struct{}  `pg:"task_field_changes"`
+	TaskID        string    `pg:"task_id,pk"`
+	Field         string    `pg:"field,pk"`
+	HistoryItemID string    `pg:"history_item_id"`
+	ChangedAt     time.Time `pg:"changed_at"`
+}
@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/provider/jira"
+)
+
+const defaultAccountsRefresh = 5 * time.Minute
+
+// AccountsReloader reloads connector pools when the accounts table changes and periodically,
+// in case a notification is lost while the listener reconnects.
+type AccountsReloader struct {
+	logger  *logrus.Logger
+	db      contract.Storage
+	clickup *clickup.ConnectorPool
+	jira    *jira.ConnectorPool
+	refresh time.Duration
+}
+
+func NewAccountsReloader(
+	cfg *config.Config,
+	logger *logrus.Logger,
+	db contract.Storage,
+	clickup *clickup.ConnectorPool,
+	jira *jira.ConnectorPool,
+) *AccountsReloader {
+	refresh := cfg.Accounts.Refresh
+	if refresh <= 0 {
+		refresh = defaultAccountsRefresh
+	}
+
+	return &AccountsReloader{
+		logger:  logger,
+		db:      db,
+		clickup: clickup,
+		jira:    jira,
+		refresh: refresh,
+	}
+}
+
+func (r *AccountsReloader) Run(ctx context.Context) {
+	changes := r.db.WatchAccounts(ctx)
+	ticker := time.NewTicker(r.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+		case <-ticker.C:
+		}
+
+		if err := r.Reload(ctx); err != nil {
+			r.logger.Error(err)
+		}
+	}
+}
+
+func (r *AccountsReloader) Reload(ctx context.Context) error {
+	ctx, span := otel.Tracer("accounts reloader").Start(ctx, "Reload")
+	defer span.End()
+
+	clickUpAccounts, err := r.db.GetClickUpAccounts(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("ClickUp accounts can't be loaded: %w", err)
+	}
+	// a partially failed reload still applies the accounts which are fine, so Jira is reloaded anyway
+	clickUpErr := r.clickup.Reload(ctx, clickUpAccounts)
+	if clickUpErr != nil {
+		span.RecordError(clickUpErr)
+	}
+
+	jiraAccounts, err := r.db.GetJiraAccounts(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("Jira accounts can't be loaded: %w", err)
+	}
+	if err = r.jira.Reload(jiraAccounts); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	span.AddEvent("accounts reloaded")
+
+	// a misconfigured Jira account is reported only, the reload is applied
+	if err = r.jira.Validate(ctx); err != nil {
+		span.RecordError(err)
+		r.logger.Error(err)
+	}
+
+	return clickUpErr
+}
@@ -7,40 +7,64 @@ import (
 	"fmt"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
 	"net/http/httputil"
+	"sync"
 )
 
 type APIClient struct {
-	httpClient http.Client
-	options    struct {
+	httpClient     http.Client
+	fieldsMu       sync.Mutex
+	fieldsResolved bool
+	options        struct {
 		host              string
 		token             string
 		listID            string
 		initialTaskStatus string
+		customFields      map[CustomFieldKey]string
+		teamID            string
 	}
 }
 
 type ClientInterface interface {
 	CreateTask(ctx context.Context, request *PutClickUpTaskRequest) (*Task, error)
 	UpdateTask(ctx context.Context, taskID string, request *PutClickUpTaskRequest) error
-	SetCustomField(ctx context.Context, taskID, customFieldID string, value interface{}) error
+	SetCustomField(ctx context.Context, taskID string, key CustomFieldKey, value interface{}) error
 	GetTask(ctx context.Context, taskID string) (*Task, error)
+	GetUser(ctx context.Context) (*User, error)
+	GetList(ctx context.Context) (*List, error)
+	GetListMembers(ctx context.Context) ([]User, error)
+	GetListFields(ctx context.Context) ([]ListField, error)
+	CustomFieldID(ctx context.Context, key CustomFieldKey) string
+	GetTeams(ctx context.Context) ([]Team, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	CreateWebhook(ctx context.Context, request *WebhookRequest) (*Webhook, error)
+	UpdateWebhook(ctx context.Context, webhookID string, request *WebhookRequest) (*Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID string) error
 	GetInitialTaskStatus(ctx context.Context) string
 }
 
 type PutClickUpTaskRequest struct {
-	Name         string        `json:"name"`
-	Description  string        `json:"description"`
-	Status       string        `json:"status,omitempty"`
-	NotifyAll    bool          `json:"notify_all,omitempty"`
-	CustomFields []CustomField `json:"custom_fields,omitempty"`
-	Tags         []string      `json:"tags"`
-	DueDate      *int64        `json:"due_date,omitempty"`
+	Name         string           `json:"name,omitempty"`
+	Description  string           `json:"description,omitempty"`
+	Status       string           `json:"status,omitempty"`
+	Priority     *int             `json:"priority,omitempty"`
+	Assignees    *AssigneesUpdate `json:"assignees,omitempty"`
+	NotifyAll    bool             `json:"notify_all,omitempty"`
+	CustomFields []CustomField    `json:"custom_fields,omitempty"`
+	Tags         []string         `json:"tags,omitempty"`
+	DueDate      *int64           `json:"due_date,omitempty"`
 }
 
-func (t *PutClickUpTaskRequest) AddCustomField(id CustomFieldKey, value interface{}) {
-	t.CustomFields = append(t.CustomFields, CustomField{ID: id, Value: value})
+type AssigneesUpdate struct {
+	Add []int `json:"add"`
+	Rem []int `json:"rem"`
+}
+
+// AddCustomField adds a value by its logical key, the client replaces keys with field IDs of the account list.
+func (t *PutClickUpTaskRequest) AddCustomField(key CustomFieldKey, value interface{}) {
+	t.CustomFields = append(t.CustomFields, CustomField{ID: string(key), Value: value})
 }
 
 func (c *APIClient) CreateTask(ctx context.Context, request *PutClickUpTaskRequest) (*Task, error) {
@@ -48,7 +72,17 @@ func (c *APIClient) CreateTask(ctx context.Context, request *PutClickUpTaskReque
 	ctx, span := otel.Tracer("clickup provider").Start(ctx, "CreateTask")
 	defer span.End()
 
-	body, err := json.Marshal(request)
+	payload := *request
+	payload.CustomFields = make([]CustomField, 0, len(request.CustomFields))
+	for _, field := range request.CustomFields {
+		if id := c.CustomFieldID(ctx, CustomFieldKey(field.ID)); id != "" {
+			payload.CustomFields = append(payload.CustomFields, CustomField{ID: id, Value: field.Value})
+		} else {
+			span.AddEvent("custom field is not mapped", trace.WithAttributes(attribute.String("key", field.ID)))
+		}
+	}
+
+	body, err := json.Marshal(payload)
 	if err != nil {
 		span.RecordError(err)
 		return nil, err
@@ -57,7 +91,7 @@ func (c *APIClient) CreateTask(ctx context.Context, request *PutClickUpTaskReque
 		attribute.String("url", c.options.host+"/list/"+c.options.listID+"/task/"),
 		attribute.String("request body", string(body)),
 	)
-	req, err := http.NewRequest("POST", c.options.host+"/list/"+c.options.listID+"/task/", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.options.host+"/list/"+c.options.listID+"/task/", bytes.NewBuffer(body))
 	if err != nil {
 		span.RecordError(err)
 		return nil, err
@@ -91,7 +125,9 @@ func (c *APIClient) UpdateTask(ctx context.Context, taskID string, request *PutC
 	ctx, span := otel.Tracer("clickup provider").Start(ctx, "UpdateTask")
 	defer span.End()
 
-	body, err := json.Marshal(request)
+	payload := *request
+	payload.CustomFields = nil
+	body, err := json.Marshal(payload)
 	if err != nil {
 		span.RecordError(err)
 		return err
@@ -100,7 +136,7 @@ func (c *APIClient) UpdateTask(ctx context.Context, taskID string, request *PutC
 		attribute.String("url", c.options.host+"/task/"+taskID),
 		attribute.String("request body", string(body)),
 	)
-	req, err := http.NewRequest("PUT", c.options.host+"/task/"+taskID, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.options.host+"/task/"+taskID, bytes.NewBuffer(body))
 	if err != nil {
 		span.RecordError(err)
 		return err
@@ -125,7 +161,7 @@ func (c *APIClient) UpdateTask(ctx context.Context, taskID string, request *PutC
 
 	var customField CustomField
 	for _, customField = range request.CustomFields {
-		err = c.SetCustomField(ctx, taskID, string(customField.ID), customField.Value)
+		err = c.SetCustomField(ctx, taskID, CustomFieldKey(customField.ID), customField.Value)
 		if err != nil {
 			span.RecordError(err)
 			return err
@@ -135,7 +171,7 @@ func (c *APIClient) UpdateTask(ctx context.Context, taskID string, request *PutC
 	return nil
 }
 
-func (c *APIClient) SetCustomField(ctx context.Context, taskID, customFieldID string, value interface{}) error {
+func (c *APIClient) SetCustomField(ctx context.Context, taskID string, key CustomFieldKey, value interface{}) error {
 	var request struct {
 		Value interface{} `json:"value"`
 	}
@@ -143,6 +179,12 @@ func (c *APIClient) SetCustomField(ctx context.Context, taskID, customFieldID st
 	ctx, span := otel.Tracer("clickup provider").Start(ctx, "SetCustomField")
 	defer span.End()
 
+	customFieldID := c.CustomFieldID(ctx, key)
+	if customFieldID == "" {
+		span.AddEvent("custom field is not mapped", trace.WithAttributes(attribute.String("key", string(key))))
+		return nil
+	}
+
 	request.Value = value
 	body, err := json.Marshal(request)
 	if err != nil {
@@ -154,7 +196,7 @@ func (c *APIClient) SetCustomField(ctx context.Context, taskID, customFieldID st
 		attribute.String("url", c.options.host+"/task/"+taskID+"/field/"+customFieldID),
 		attribute.String("request body", string(body)),
 	)
-	req, err := http.NewRequest("POST", c.options.host+"/task/"+taskID+"/field/"+customFieldID, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.options.host+"/task/"+taskID+"/field/"+customFieldID, bytes.NewBuffer(body))
 	if err != nil {
 		span.RecordError(err)
 		return err
@@ -188,7 +230,7 @@ func (c *APIClient) GetTask(ctx context.Context, taskID string) (*Task, error) {
 		attribute.String("url", c.options.host+"/task/"+taskID),
 	)
 
-	req, err := http.NewRequest("GET", c.options.host+"/task/"+taskID, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.options.host+"/task/"+taskID, nil)
 	if err != nil {
 		span.RecordError(err)
 		return nil, err
@@ -219,6 +261,133 @@ func (c *APIClient) GetTask(ctx context.Context, taskID string) (*Task, error) {
 	return &task, nil
 }
 
+// GetUser returns the user the token belongs to.
+func (c *APIClient) GetUser(ctx context.Context) (*User, error) {
+	var response struct {
+		User User `json:"user"`
+	}
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "GetUser")
+	defer span.End()
+
+	if err := c.call(ctx, http.MethodGet, "/user", nil, &response); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &response.User, nil
+}
+
+func (c *APIClient) GetList(ctx context.Context) (*List, error) {
+	var list List
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "GetList")
+	defer span.End()
+
+	if err := c.call(ctx, http.MethodGet, "/list/"+c.options.listID, nil, &list); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+func (c *APIClient) GetListMembers(ctx context.Context) ([]User, error) {
+	var response struct {
+		Members []User `json:"members"`
+	}
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "GetListMembers")
+	defer span.End()
+
+	if err := c.call(ctx, http.MethodGet, "/list/"+c.options.listID+"/member", nil, &response); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return response.Members, nil
+}
+
+func (c *APIClient) GetListFields(ctx context.Context) ([]ListField, error) {
+	var response struct {
+		Fields []ListField `json:"fields"`
+	}
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "GetListFields")
+	defer span.End()
+
+	if err := c.call(ctx, http.MethodGet, "/list/"+c.options.listID+"/field", nil, &response); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return response.Fields, nil
+}
+
+// CustomFieldID resolves the custom fields on the first use when they couldn't be resolved on load, till then
+// only the fields mapped by the account are known.
+func (c *APIClient) CustomFieldID(ctx context.Context, key CustomFieldKey) string {
+	c.fieldsMu.Lock()
+	resolved := c.fieldsResolved
+	c.fieldsMu.Unlock()
+
+	if !resolved {
+		_ = c.resolveCustomFields(ctx)
+	}
+
+	c.fieldsMu.Lock()
+	defer c.fieldsMu.Unlock()
+
+	return c.options.customFields[key]
+}
+
+// resolveCustomFields validates mapped field IDs against the list and maps the rest of keys by field names.
+func (c *APIClient) resolveCustomFields(ctx context.Context) error {
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "resolveCustomFields")
+	defer span.End()
+
+	fields, err := c.GetListFields(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	c.fieldsMu.Lock()
+	defer c.fieldsMu.Unlock()
+
+	ids := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		ids[field.ID] = struct{}{}
+	}
+	customFields := make(map[CustomFieldKey]string, len(CustomFieldKeys))
+	for key, id := range c.options.customFields {
+		if _, ok := ids[id]; !ok {
+			err = fmt.Errorf("custom field %s (%s) is not found in list %s: %w", key, id, c.options.listID, ErrCustomFieldNotFound)
+			span.RecordError(err)
+			return err
+		}
+		customFields[key] = id
+	}
+
+	for _, key := range CustomFieldKeys {
+		if _, ok := customFields[key]; ok {
+			continue
+		}
+		for _, field := range fields {
+			if key.Matches(field.Name) {
+				customFields[key] = field.ID
+				span.AddEvent("custom field resolved", trace.WithAttributes(
+					attribute.String("key", string(key)),
+					attribute.String("id", field.ID),
+				))
+				break
+			}
+		}
+	}
+
+	// the map is replaced rather than updated, since callers read it without the lock
+	c.options.customFields = customFields
+	c.fieldsResolved = true
+
+	return nil
+}
+
 func (c *APIClient) GetInitialTaskStatus(ctx context.Context) string {
 	ctx, span := otel.Tracer("clickup provider").Start(ctx, "GetInitialTaskStatus")
 	defer span.End()
@@ -226,6 +395,11 @@ func (c *APIClient) GetInitialTaskStatus(ctx context.Context) string {
 }
 
 func formatHttpError(r *http.Response) error {
+	defer r.Body.Close()
 	dump, _ := httputil.DumpResponse(r, true)
-	return fmt.Errorf("ClickUp API error status: %s body: %q", r.Status, dump)
+	return &APIError{
+		StatusCode: r.StatusCode,
+		Status:     r.Status,
+		Body:       string(dump),
+	}
 }
@@ -1,38 +1,268 @@
 package clickup
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+
+	"x-qdo/jiraclick/pkg/metrics"
 	"x-qdo/jiraclick/pkg/model"
 )
 
+const resource = "clickup"
+
 type ConnectorPool struct {
-	clients map[string]ClientInterface
+	mu            sync.RWMutex
+	accounts      map[string]model.ClickUpAccount
+	clients       map[string]ClientInterface
+	aliases       map[string]string
+	secrets       map[string][]string
+	defaultTenant string
+}
+
+// NewClickUpConnector returns the pool with the accounts which are loaded together with the failures of the others,
+// so a misconfigured account doesn't stop the commands which are needed to fix it.
+func NewClickUpConnector(ctx context.Context, accounts map[string]model.ClickUpAccount) (*ConnectorPool, error) {
+	pool := new(ConnectorPool)
+
+	return pool, pool.Reload(ctx, accounts)
 }
 
-func NewClickUpConnector(accounts map[string]model.ClickUpAccount) (*ConnectorPool, error) {
+// Reload swaps the accounts of the pool. Clients of unchanged accounts are kept together with their rate limit
+// budget, an account which can't be loaded keeps its previous client. Requests in flight finish on the client
+// they've got.
+func (pool *ConnectorPool) Reload(ctx context.Context, accounts map[string]model.ClickUpAccount) error {
+	var (
+		failures      []string
+		defaultTenant string
+	)
+
+	pool.mu.RLock()
+	previousAccounts, previousClients := pool.accounts, pool.clients
+	pool.mu.RUnlock()
+
+	accs := make(map[string]model.ClickUpAccount)
 	clients := make(map[string]ClientInterface)
+	aliases := make(map[string]string)
+	secrets := make(map[string][]string)
+
+	for _, name := range sortedTenants(accounts) {
+		account := accounts[name]
+		tenant := strings.ToLower(name)
+		previous, found := previousClients[tenant]
+
+		if found && reflect.DeepEqual(previousAccounts[tenant], account) {
+			clients[tenant] = previous
+		} else if client, err := newAPIClient(ctx, tenant, account); err == nil {
+			clients[tenant] = client
+		} else {
+			failures = append(failures, fmt.Sprintf("tenant %s: can't resolve ClickUp custom fields: %s", tenant, err.Error()))
+			if !found {
+				continue
+			}
+			clients[tenant] = previous
+			account = previousAccounts[tenant]
+		}
+		accs[tenant] = account
+		secrets[tenant] = webhookSecrets(account)
 
-	for tenant, account := range accounts {
-		tenant = strings.ToLower(tenant)
-		client := new(APIClient)
-		client.options.host = account.Host
-		client.options.token = account.Token
-		client.options.listID = account.List
-		client.options.initialTaskStatus = account.InitialTaskStatus
+		for _, alias := range account.Aliases {
+			aliases[strings.ToLower(alias)] = tenant
+		}
+		if account.Default {
+			if defaultTenant != "" {
+				failures = append(failures, fmt.Sprintf("tenants %s and %s are both default, %s is used", defaultTenant, tenant, defaultTenant))
+				continue
+			}
+			defaultTenant = tenant
+		}
+	}
+
+	pool.mu.Lock()
+	pool.accounts = accs
+	pool.clients = clients
+	pool.aliases = aliases
+	pool.secrets = secrets
+	pool.defaultTenant = defaultTenant
+	pool.mu.Unlock()
 
-		clients[tenant] = client
+	if len(failures) > 0 {
+		return fmt.Errorf("ClickUp accounts are misconfigured: %s", strings.Join(failures, "; "))
 	}
 
-	return &ConnectorPool{
-		clients: clients,
-	}, nil
+	return nil
 }
 
-func (pool *ConnectorPool) GetInstance(tenant string) ClientInterface {
+func sortedTenants(accounts map[string]model.ClickUpAccount) []string {
+	tenants := make([]string, 0, len(accounts))
+	for tenant := range accounts {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	return tenants
+}
+
+// webhookSecrets returns the current secret first, since most webhooks are signed by it.
+func webhookSecrets(account model.ClickUpAccount) []string {
+	var secrets []string
+
+	for _, secret := range append([]string{account.WebhookSecret}, account.WebhookSecrets...) {
+		if secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	return secrets
+}
+
+// newAPIClient fails only when a mapped custom field is missing in the list. When the list can't be fetched,
+// e.g. ClickUp is briefly down, the fields are resolved on the first use instead of dropping the tenant.
+func newAPIClient(ctx context.Context, tenant string, account model.ClickUpAccount) (*APIClient, error) {
+	client := NewAPIClient(tenant, account)
+	if err := client.resolveCustomFields(ctx); errors.Is(err, ErrCustomFieldNotFound) {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// NewAPIClient creates a client with the custom fields mapped by the account only, no requests are sent.
+func NewAPIClient(tenant string, account model.ClickUpAccount) *APIClient {
+	client := new(APIClient)
+	client.httpClient = newHTTPClient(tenant, account.RequestsPerMinute)
+	client.options.host = account.Host
+	client.options.token = account.Token
+	client.options.listID = account.List
+	client.options.initialTaskStatus = account.InitialTaskStatus
+	client.options.teamID = account.Team
+	client.options.customFields = make(map[CustomFieldKey]string)
+	for key, id := range account.CustomFields {
+		client.options.customFields[CustomFieldKey(key)] = id
+	}
+
+	return client
+}
+
+func (pool *ConnectorPool) GetInstance(tenant string) (ClientInterface, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	account, err := pool.resolve(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return pool.clients[account], nil
+}
+
+// GetTenantInstance returns the client of the tenant or its alias without the default account fallback,
+// so admin commands never act on behalf of another tenant.
+func (pool *ConnectorPool) GetTenantInstance(tenant string) (ClientInterface, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	account, ok := pool.lookup(tenant)
+	if !ok {
+		return nil, &model.UnknownTenantError{Resource: resource, Tenant: strings.ToLower(tenant)}
+	}
+
+	return pool.clients[account], nil
+}
+
+// GetAccounts returns the loaded accounts by tenant.
+func (pool *ConnectorPool) GetAccounts() map[string]model.ClickUpAccount {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	accounts := make(map[string]model.ClickUpAccount, len(pool.accounts))
+	for tenant, account := range pool.accounts {
+		accounts[tenant] = account
+	}
+
+	return accounts
+}
+
+// WebhookSecrets returns the accepted webhook secrets of the tenant or its alias. The default account isn't
+// used for unknown tenants, since a webhook mustn't be accepted on behalf of another tenant.
+func (pool *ConnectorPool) WebhookSecrets(tenant string) (string, []string, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	tenant = strings.ToLower(tenant)
+	if account, ok := pool.aliases[tenant]; ok {
+		tenant = account
+	}
+	secrets, ok := pool.secrets[tenant]
+	if !ok {
+		return "", nil, &model.UnknownTenantError{Resource: resource, Tenant: tenant}
+	}
+
+	return tenant, secrets, nil
+}
+
+// TenantSecrets are the accepted webhook secrets of a tenant.
+type TenantSecrets struct {
+	Tenant  string
+	Secrets []string
+}
+
+// AllWebhookSecrets returns the accepted webhook secrets sorted by tenant, so signatures are checked in a stable order.
+func (pool *ConnectorPool) AllWebhookSecrets() []TenantSecrets {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	secrets := make([]TenantSecrets, 0, len(pool.secrets))
+	for tenant, s := range pool.secrets {
+		secrets = append(secrets, TenantSecrets{Tenant: tenant, Secrets: s})
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Tenant < secrets[j].Tenant
+	})
+
+	return secrets
+}
+
+// CheckTenant returns UnknownTenantError when neither an account nor an alias matches the tenant,
+// even though the default account serves it.
+func (pool *ConnectorPool) CheckTenant(tenant string) error {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if _, ok := pool.lookup(tenant); !ok {
+		return &model.UnknownTenantError{Resource: resource, Tenant: strings.ToLower(tenant)}
+	}
+
+	return nil
+}
+
+// lookup returns the account of the tenant or of the alias.
+func (pool *ConnectorPool) lookup(tenant string) (string, bool) {
 	tenant = strings.ToLower(tenant)
-	if _, ok := pool.clients[tenant]; !ok {
-		panic(fmt.Sprintf("tenant %s must be declared in config.yaml file", tenant))
+	if _, ok := pool.clients[tenant]; ok {
+		return tenant, true
+	}
+	if account, ok := pool.aliases[tenant]; ok {
+		return account, true
+	}
+
+	return "", false
+}
+
+// resolve returns the account of the tenant, of the alias or the default account.
+func (pool *ConnectorPool) resolve(tenant string) (string, error) {
+	if account, ok := pool.lookup(tenant); ok {
+		return account, nil
 	}
-	return pool.clients[tenant]
+	if pool.defaultTenant != "" {
+		metrics.UnknownTenantLookup(resource, metrics.TenantFallback)
+		return pool.defaultTenant, nil
+	}
+	metrics.UnknownTenantLookup(resource, metrics.TenantRejected)
+
+	return "", &model.UnknownTenantError{Resource: resource, Tenant: strings.ToLower(tenant)}
 }
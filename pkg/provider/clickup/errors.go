@@ -0,0 +1,59 @@
+package clickup
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrRateLimited  = errors.New("ClickUp API rate limit is exceeded")
+	ErrNotFound     = errors.New("ClickUp API resource is not found")
+	ErrUnauthorized = errors.New("ClickUp API token is not authorized")
+	ErrValidation   = errors.New("ClickUp API request is not valid")
+	ErrUnavailable  = errors.New("ClickUp API is unavailable")
+
+	ErrCustomFieldNotFound = errors.New("ClickUp custom field is not found")
+)
+
+// APIError is returned for every non-200 response; errors.Is matches it against the Err* kinds above.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ClickUp API error status: %s body: %q", e.Status, e.Body)
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrUnavailable
+	}
+
+	return nil
+}
+
+// IsTemporary reports whether the request may succeed later, so the message is worth requeueing.
+func IsTemporary(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
+
+// IsPermanent reports whether the request can't succeed on a retry, so the message is dead-lettered at once.
+func IsPermanent(err error) bool {
+	if IsTemporary(err) {
+		return false
+	}
+
+	return errors.Is(err, ErrValidation) || errors.Is(err, ErrUnauthorized)
+}
@@ -1,19 +1,48 @@
 package clickup
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
 
+// CustomFieldKey is a logical name of a custom field, every account maps it to the field ID of its list.
 type CustomFieldKey string
 
 const (
-	ApprovedBy       CustomFieldKey = "065a1567-0655-4a7e-aefe-e179f7983069"
-	BillableHours    CustomFieldKey = "074e1387-e7b8-41c6-92db-fbada8f8486c"
-	JiraLink         CustomFieldKey = "349fbec4-f71f-4cee-9861-c112e253a6e1"
-	SlackLink        CustomFieldKey = "517a450f-ce8b-4683-b34a-616d5c3b0fb4"
-	DoneNotification CustomFieldKey = "86477c9c-b494-423b-8dc0-3a49734b8b28"
-	Synced           CustomFieldKey = "926d35a9-5f70-4f54-bc07-d11b82d4cf21"
-	RequestedBy      CustomFieldKey = "eb30f61c-dbad-4ad4-896d-15d2a239cb69"
+	ApprovedBy       CustomFieldKey = "approved_by"
+	BillableHours    CustomFieldKey = "billable_hours"
+	JiraLink         CustomFieldKey = "jira_link"
+	SlackLink        CustomFieldKey = "slack_link"
+	DoneNotification CustomFieldKey = "done_notification"
+	Synced           CustomFieldKey = "synced"
+	RequestedBy      CustomFieldKey = "requested_by"
 )
 
+var CustomFieldKeys = []CustomFieldKey{
+	ApprovedBy,
+	BillableHours,
+	JiraLink,
+	SlackLink,
+	DoneNotification,
+	Synced,
+	RequestedBy,
+}
+
+// Matches reports whether the list field name corresponds to the key, e.g. "Slack Link" to slack_link.
+func (k CustomFieldKey) Matches(fieldName string) bool {
+	return normalizeFieldName(string(k)) == normalizeFieldName(fieldName)
+}
+
+func normalizeFieldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
 type TaskStatus string
 
 type Task struct {
@@ -58,14 +87,34 @@ type User struct {
 }
 
 type CustomField struct {
-	ID    CustomFieldKey `json:"id"`
-	Name  string         `json:"name"`
-	Value interface{}    `json:"value"`
+	ID    string      `json:"id"`
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
 }
 
-func (t *Task) GetSlackChannel() string {
+type List struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Statuses []ListStatus `json:"statuses"`
+}
+
+type ListStatus struct {
+	Status string `json:"status"`
+	Type   string `json:"type"`
+}
+
+type ListField struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func (t *Task) GetSlackChannel(slackLinkFieldID string) string {
+	if slackLinkFieldID == "" {
+		return ""
+	}
 	for _, field := range t.CustomFields {
-		if field.ID == SlackLink {
+		if field.ID == slackLinkFieldID {
 			link, ok := field.Value.(string)
 			if !ok {
 				return ""
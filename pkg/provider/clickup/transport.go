@@ -0,0 +1,217 @@
+package clickup
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"x-qdo/jiraclick/pkg/metrics"
+)
+
+const (
+	defaultRequestsPerMinute = 100
+	responseHeaderTimeout    = 30 * time.Second
+	maxRetries               = 4
+	baseBackoff              = 500 * time.Millisecond
+	maxBackoff               = 30 * time.Second
+)
+
+// transport keeps requests of one account within its budget, pauses when ClickUp reports
+// the rate limit is exhausted and retries rate limited and failed requests with jittered backoff.
+type transport struct {
+	base         http.RoundTripper
+	budget       *budget
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+// newHTTPClient measures every attempt, so rate limited and retried requests are visible in metrics.
+func newHTTPClient(tenant string, requestsPerMinute int) http.Client {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = defaultRequestsPerMinute
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = responseHeaderTimeout
+
+	return http.Client{
+		Transport: &transport{
+			base:   metrics.NewTransport(resource, tenant, base),
+			budget: newBudget(requestsPerMinute, time.Minute),
+		},
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		r, err := t.base.RoundTrip(req)
+		if err == nil {
+			t.observe(r)
+		}
+		if attempt == maxRetries || !isRetryable(req, r, err) {
+			return r, err
+		}
+
+		delay := backoff(attempt, r)
+		if r != nil {
+			_, _ = io.Copy(ioutil.Discard, r.Body)
+			_ = r.Body.Close()
+		}
+		if req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *transport) wait(ctx context.Context) error {
+	t.mu.Lock()
+	blockedUntil := t.blockedUntil
+	t.mu.Unlock()
+
+	if err := sleep(ctx, time.Until(blockedUntil)); err != nil {
+		return err
+	}
+
+	return t.budget.wait(ctx)
+}
+
+// observe blocks further requests till the reset time once ClickUp reports no remaining requests.
+func (t *transport) observe(r *http.Response) {
+	if r.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	if reset := parseRateLimitReset(r); !reset.IsZero() {
+		t.mu.Lock()
+		t.blockedUntil = reset
+		t.mu.Unlock()
+	}
+}
+
+func isRetryable(req *http.Request, r *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodPut || req.Method == http.MethodDelete
+	if err != nil {
+		return idempotent && req.Context().Err() == nil
+	}
+	if r.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	return idempotent && r.StatusCode >= http.StatusInternalServerError
+}
+
+func backoff(attempt int, r *http.Response) time.Duration {
+	if r != nil && r.StatusCode == http.StatusTooManyRequests {
+		if reset := parseRateLimitReset(r); !reset.IsZero() {
+			if delay := time.Until(reset); delay > 0 && delay < maxBackoff {
+				return delay + jitter(baseBackoff)
+			}
+		}
+	}
+
+	delay := baseBackoff << uint(attempt)
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return delay/2 + jitter(delay/2)
+}
+
+func parseRateLimitReset(r *http.Response) time.Time {
+	if reset, err := strconv.ParseInt(r.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0)
+	}
+	if seconds, err := strconv.Atoi(r.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+
+	return time.Time{}
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max))) // nolint:gosec
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// budget is a token bucket refilled by limit tokens per period.
+type budget struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64
+	last     time.Time
+}
+
+func newBudget(limit int, period time.Duration) *budget {
+	return &budget{
+		capacity: float64(limit),
+		tokens:   float64(limit),
+		rate:     float64(limit) / period.Seconds(),
+		last:     time.Now(),
+	}
+}
+
+func (b *budget) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package clickup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const WebhookStatusActive = "active"
+
+type Webhook struct {
+	ID       string   `json:"id"`
+	UserID   int      `json:"userid"`
+	TeamID   int      `json:"team_id"`
+	Endpoint string   `json:"endpoint"`
+	Events   []string `json:"events"`
+	TaskID   *string  `json:"task_id"`
+	ListID   *int     `json:"list_id"`
+	FolderID *int     `json:"folder_id"`
+	SpaceID  *int     `json:"space_id"`
+	Health   struct {
+		Status    string `json:"status"`
+		FailCount int    `json:"fail_count"`
+	} `json:"health"`
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookRequest struct {
+	Endpoint string   `json:"endpoint,omitempty"`
+	Events   []string `json:"events,omitempty"`
+	ListID   string   `json:"list_id,omitempty"`
+	Status   string   `json:"status,omitempty"`
+}
+
+type webhookResponse struct {
+	ID      string  `json:"id"`
+	Webhook Webhook `json:"webhook"`
+}
+
+type Team struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (c *APIClient) GetTeams(ctx context.Context) ([]Team, error) {
+	var response struct {
+		Teams []Team `json:"teams"`
+	}
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "GetTeams")
+	defer span.End()
+
+	if err := c.call(ctx, http.MethodGet, "/team", nil, &response); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return response.Teams, nil
+}
+
+func (c *APIClient) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var response struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "ListWebhooks")
+	defer span.End()
+
+	teamID, err := c.getTeamID(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if err = c.call(ctx, http.MethodGet, "/team/"+teamID+"/webhook", nil, &response); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return response.Webhooks, nil
+}
+
+// CreateWebhook registers a webhook for the account list; the returned webhook contains the secret.
+func (c *APIClient) CreateWebhook(ctx context.Context, request *WebhookRequest) (*Webhook, error) {
+	var response webhookResponse
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "CreateWebhook")
+	defer span.End()
+
+	teamID, err := c.getTeamID(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if request.ListID == "" {
+		request.ListID = c.options.listID
+	}
+	if err = c.call(ctx, http.MethodPost, "/team/"+teamID+"/webhook", request, &response); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &response.Webhook, nil
+}
+
+func (c *APIClient) UpdateWebhook(ctx context.Context, webhookID string, request *WebhookRequest) (*Webhook, error) {
+	var response webhookResponse
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "UpdateWebhook")
+	defer span.End()
+
+	if err := c.call(ctx, http.MethodPut, "/webhook/"+webhookID, request, &response); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &response.Webhook, nil
+}
+
+func (c *APIClient) DeleteWebhook(ctx context.Context, webhookID string) error {
+	ctx, span := otel.Tracer("clickup provider").Start(ctx, "DeleteWebhook")
+	defer span.End()
+
+	if err := c.call(ctx, http.MethodDelete, "/webhook/"+webhookID, nil, nil); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// getTeamID returns the configured team or the only team the token has access to.
+func (c *APIClient) getTeamID(ctx context.Context) (string, error) {
+	if c.options.teamID != "" {
+		return c.options.teamID, nil
+	}
+
+	teams, err := c.GetTeams(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(teams) != 1 {
+		return "", fmt.Errorf("token has access to %d teams, `team` must be set in the account props", len(teams))
+	}
+
+	return teams[0].ID, nil
+}
+
+func (c *APIClient) call(ctx context.Context, method, path string, request, response interface{}) error {
+	var body io.Reader
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("url", c.options.host+path))
+	if request != nil {
+		b, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		span.SetAttributes(attribute.String("request body", string(b)))
+		body = bytes.NewBuffer(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.options.host+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", c.options.token)
+	req.Header.Add("Content-Type", "application/json")
+
+	r, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	span.AddEvent(method + " request sent to ClickUp")
+
+	if r.StatusCode != http.StatusOK {
+		return formatHttpError(r)
+	}
+	defer r.Body.Close()
+
+	if response == nil {
+		return nil
+	}
+
+	return json.NewDecoder(r.Body).Decode(response)
+}
@@ -6,6 +6,10 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
 	"go.opentelemetry.io/otel"
 )
 
@@ -27,6 +31,22 @@ const (
 	TaskTimeTrackedUpdated  EventType = "taskTimeTrackedUpdated"
 )
 
+var TaskEventTypes = []EventType{
+	TaskCreated,
+	TaskUpdated,
+	TaskDeleted,
+	TaskPriorityUpdated,
+	TaskStatusUpdated,
+	TaskAssigneeUpdated,
+	TaskDueDateUpdated,
+	TaskTagUpdated,
+	TaskMoved,
+	TaskCommentPosted,
+	TaskCommentUpdated,
+	TaskTimeEstimateUpdated,
+	TaskTimeTrackedUpdated,
+}
+
 type WebhookEvent struct {
 	ID      string        `json:"webhook_id"`
 	Type    EventType     `json:"event"`
@@ -35,13 +55,212 @@ type WebhookEvent struct {
 }
 
 type HistoryItem struct {
-	ID     string      `json:"id"`
-	Type   int         `json:"type"`
-	Date   string      `json:"date"`
-	Field  string      `json:"field"`
-	User   User        `json:"user"`
-	Before interface{} `json:"before"`
-	After  interface{} `json:"after"`
+	ID          string      `json:"id"`
+	Type        int         `json:"type"`
+	Date        string      `json:"date"`
+	Field       string      `json:"field"`
+	User        User        `json:"user"`
+	Before      interface{} `json:"before"`
+	After       interface{} `json:"after"`
+	Comment     *Comment    `json:"comment,omitempty"`
+	CustomField *ListField  `json:"custom_field,omitempty"`
+}
+
+// Fields of history items.
+const (
+	FieldName         = "name"
+	FieldContent      = "content"
+	FieldStatus       = "status"
+	FieldPriority     = "priority"
+	FieldAssigneeAdd  = "assignee_add"
+	FieldAssigneeRem  = "assignee_rem"
+	FieldDueDate      = "due_date"
+	FieldStartDate    = "start_date"
+	FieldTag          = "tag"
+	FieldTagRemoved   = "tag_removed"
+	FieldSectionMoved = "section_moved"
+	FieldComment      = "comment"
+	FieldTimeEstimate = "time_estimate"
+	FieldTimeSpent    = "time_spent"
+	FieldTaskCreation = "task_creation"
+	FieldCustomField  = "custom_field"
+)
+
+type HistoryStatus struct {
+	Status string `json:"status"`
+	Color  string `json:"color,omitempty"`
+	Type   string `json:"type,omitempty"`
+}
+
+type HistoryPriority struct {
+	ID       string `json:"id"`
+	Priority string `json:"priority"`
+	Color    string `json:"color,omitempty"`
+}
+
+type Tag struct {
+	Name       string `json:"name"`
+	Foreground string `json:"tag_fg,omitempty"`
+	Background string `json:"tag_bg,omitempty"`
+}
+
+// HistoryList is the list which a task is moved to or from.
+type HistoryList struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Comment struct {
+	ID          string `json:"id"`
+	TextContent string `json:"text_content"`
+}
+
+// TimeEntry is the tracked interval of a task, the time is in milliseconds.
+type TimeEntry struct {
+	ID    string `json:"id"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Time  string `json:"time"`
+}
+
+type historyDecoder func(value interface{}) (interface{}, error)
+
+var historyDecoders = map[string]historyDecoder{
+	FieldStatus:       decodeAs(func() interface{} { return new(HistoryStatus) }),
+	FieldPriority:     decodeAs(func() interface{} { return new(HistoryPriority) }),
+	FieldAssigneeAdd:  decodeAs(func() interface{} { return new(User) }),
+	FieldAssigneeRem:  decodeAs(func() interface{} { return new(User) }),
+	FieldDueDate:      decodeTime,
+	FieldStartDate:    decodeTime,
+	FieldTag:          decodeTags,
+	FieldTagRemoved:   decodeTags,
+	FieldSectionMoved: decodeAs(func() interface{} { return new(HistoryList) }),
+	FieldTimeEstimate: decodeDuration,
+	FieldTimeSpent:    decodeAs(func() interface{} { return new(TimeEntry) }),
+}
+
+// Decode returns typed before and after values of the item:
+//
+//	status: *HistoryStatus
+//	priority: *HistoryPriority
+//	assignee_add, assignee_rem: *User
+//	due_date, start_date: *time.Time
+//	tag, tag_removed: []Tag
+//	section_moved: *HistoryList
+//	comment: *Comment as the after value
+//	time_estimate: *time.Duration
+//	time_spent: *TimeEntry
+//	custom_field: *CustomField
+//
+// Values of other fields, e.g. name and content of taskUpdated, are returned as is.
+// A value, which is empty in the event, is nil.
+func (i HistoryItem) Decode() (before, after interface{}, err error) {
+	if i.Field == FieldComment {
+		if i.Comment == nil {
+			return nil, nil, nil
+		}
+		return nil, i.Comment, nil
+	}
+	if i.Field == FieldCustomField && i.CustomField != nil {
+		return i.customFieldValue(i.Before), i.customFieldValue(i.After), nil
+	}
+
+	decode, ok := historyDecoders[i.Field]
+	if !ok {
+		return i.Before, i.After, nil
+	}
+
+	if before, err = decode(i.Before); err != nil {
+		return nil, nil, fmt.Errorf("history item %s: before %s: %w", i.ID, i.Field, err)
+	}
+	if after, err = decode(i.After); err != nil {
+		return nil, nil, fmt.Errorf("history item %s: after %s: %w", i.ID, i.Field, err)
+	}
+
+	return before, after, nil
+}
+
+func (i HistoryItem) customFieldValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	return &CustomField{
+		ID:    i.CustomField.ID,
+		Name:  i.CustomField.Name,
+		Value: value,
+	}
+}
+
+func decodeAs(newValue func() interface{}) historyDecoder {
+	return func(value interface{}) (interface{}, error) {
+		if value == nil {
+			return nil, nil
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		v := newValue()
+		if err = json.Unmarshal(data, v); err != nil {
+			return nil, err
+		}
+
+		return v, nil
+	}
+}
+
+func decodeTags(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	tags, err := decodeAs(func() interface{} { return &[]Tag{} })(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return *tags.(*[]Tag), nil
+}
+
+func decodeTime(value interface{}) (interface{}, error) {
+	ms, ok, err := milliseconds(value)
+	if !ok || err != nil {
+		return nil, err
+	}
+	t := time.Unix(0, ms*int64(time.Millisecond)).UTC()
+
+	return &t, nil
+}
+
+func decodeDuration(value interface{}) (interface{}, error) {
+	ms, ok, err := milliseconds(value)
+	if !ok || err != nil {
+		return nil, err
+	}
+	d := time.Duration(ms) * time.Millisecond
+
+	return &d, nil
+}
+
+// milliseconds reads ClickUp timestamps and durations, which are sent as strings or numbers.
+func milliseconds(value interface{}) (int64, bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, false, nil
+	case string:
+		if v == "" {
+			return 0, false, nil
+		}
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, false, err
+		}
+		return ms, true, nil
+	case float64:
+		return int64(v), true, nil
+	default:
+		return 0, false, fmt.Errorf("unexpected value %v", value)
+	}
 }
 
 func CheckSignature(ctx context.Context, signature, body, secret string) bool {
@@ -0,0 +1,97 @@
+package clickup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+type decodedItem struct {
+	Field      string      `json:"field"`
+	BeforeType string      `json:"before_type"`
+	Before     interface{} `json:"before"`
+	AfterType  string      `json:"after_type"`
+	After      interface{} `json:"after"`
+}
+
+func TestHistoryItemDecode(t *testing.T) {
+	tests := []string{
+		"status",
+		"priority",
+		"assignee_add",
+		"assignee_rem",
+		"tag",
+		"tag_removed",
+		"comment",
+		"time_spent",
+		"section_moved",
+		"due_date",
+		"time_estimate",
+		"custom_field",
+	}
+
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, err := ioutil.ReadFile(filepath.Join("testdata", name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			event, err := ParseEvent(context.Background(), string(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event == nil {
+				t.Fatal("event without history items")
+			}
+
+			decoded := make([]decodedItem, 0, len(event.Changes))
+			for _, item := range event.Changes {
+				if item.Field != name {
+					t.Errorf("field is %q, want %q", item.Field, name)
+				}
+				before, after, err := item.Decode()
+				if err != nil {
+					t.Fatal(err)
+				}
+				decoded = append(decoded, decodedItem{
+					Field:      item.Field,
+					BeforeType: fmt.Sprintf("%T", before),
+					Before:     before,
+					AfterType:  fmt.Sprintf("%T", after),
+					After:      after,
+				})
+			}
+
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if err = encoder.Encode(decoded); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err = ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("decoded items don't match %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
@@ -11,14 +11,23 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"time"
 )
 
-const LinkToJiraTask = "%s/browse/%s"
+const (
+	LinkToJiraTask = "%s/browse/%s"
+	JiraDateFormat = "2006-01-02"
+)
 
 type ClientInterface interface {
 	CreateIssue(ctx context.Context, task *Task) (*PutJiraTaskResponse, error)
-	UpdateIssue(ctx context.Context) error
+	UpdateIssue(ctx context.Context, task *Task) error
+	TransitionIssue(ctx context.Context, issueID, transition, status string) error
+	CheckCreateMeta(ctx context.Context, issueType string, fields []string) error
 	FindUserByEmail(ctx context.Context, email string) *jira.User
+	GetMyself(ctx context.Context) (*jira.User, error)
+	GetProject(ctx context.Context) (*jira.Project, error)
 }
 
 type jiraClient struct {
@@ -33,6 +42,11 @@ type Task struct {
 	Description  string
 	Reporter     string
 	Type         string
+	Priority     string
+	Assignee     string
+	Components   []string
+	Labels       []string
+	DueDate      *time.Time
 	CustomFields tcontainer.MarshalMap
 }
 
@@ -65,9 +79,16 @@ func (c *jiraClient) CreateIssue(ctx context.Context, task *Task) (*PutJiraTaskR
 				Key: c.project,
 			},
 			Summary:  task.Title,
+			Labels:   task.Labels,
 			Unknowns: task.CustomFields,
 		},
 	}
+	if task.Priority != "" {
+		i.Fields.Priority = &jira.Priority{Name: task.Priority}
+	}
+	for _, component := range task.Components {
+		i.Fields.Components = append(i.Fields.Components, &jira.Component{Name: component})
+	}
 
 	issue, r, err := c.client.Issue.CreateWithContext(ctx, &i)
 	if err != nil {
@@ -91,12 +112,185 @@ func (c *jiraClient) CreateIssue(ctx context.Context, task *Task) (*PutJiraTaskR
 	return &response, nil
 }
 
-func (c *jiraClient) UpdateIssue(ctx context.Context) error {
+func (c *jiraClient) UpdateIssue(ctx context.Context, task *Task) error {
 	ctx, span := otel.Tracer("jira client").Start(ctx, "UpdateIssue")
 	defer span.End()
+
+	if task.ID == "" {
+		err := errors.New("issue id is not defined")
+		span.RecordError(err)
+		return err
+	}
+
+	t, err := json.Marshal(task)
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		span.SetAttributes(attribute.Key("task").String(string(t)))
+	}
+
+	fields := make(map[string]interface{})
+	for key, value := range task.CustomFields {
+		fields[key] = value
+	}
+	if task.Title != "" {
+		fields["summary"] = task.Title
+	}
+	if task.Description != "" {
+		fields["description"] = task.Description
+	}
+	if task.Reporter != "" {
+		if reporter := c.FindUserByEmail(ctx, task.Reporter); reporter != nil {
+			fields["reporter"] = reporter
+		}
+	}
+	if task.Assignee != "" {
+		if assignee := c.FindUserByEmail(ctx, task.Assignee); assignee != nil {
+			fields["assignee"] = assignee
+		}
+	}
+	if task.Priority != "" {
+		fields["priority"] = map[string]string{"name": task.Priority}
+	}
+	if len(task.Labels) > 0 {
+		fields["labels"] = task.Labels
+	}
+	if len(task.Components) > 0 {
+		components := make([]map[string]string, 0, len(task.Components))
+		for _, component := range task.Components {
+			components = append(components, map[string]string{"name": component})
+		}
+		fields["components"] = components
+	}
+	if task.DueDate != nil {
+		fields["duedate"] = task.DueDate.Format(JiraDateFormat)
+	}
+
+	r, err := c.client.Issue.UpdateIssueWithContext(ctx, task.ID, map[string]interface{}{"fields": fields})
+	if err != nil {
+		err = jira.NewJiraError(r, err)
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("issue has been updated", trace.WithAttributes(
+		attribute.Key("issue id").String(task.ID),
+	))
+
 	return nil
 }
 
+// TransitionIssue executes the transition found by its ID or name; when transition is empty,
+// the first available transition leading to status is executed.
+func (c *jiraClient) TransitionIssue(ctx context.Context, issueID, transition, status string) error {
+	ctx, span := otel.Tracer("jira client").Start(ctx, "TransitionIssue")
+	defer span.End()
+	span.SetAttributes(
+		attribute.Key("issue id").String(issueID),
+		attribute.Key("transition").String(transition),
+		attribute.Key("status").String(status),
+	)
+
+	transitions, r, err := c.client.Issue.GetTransitionsWithContext(ctx, issueID)
+	if err != nil {
+		err = jira.NewJiraError(r, err)
+		span.RecordError(err)
+		return err
+	}
+
+	for _, t := range transitions {
+		matched := strings.EqualFold(t.To.Name, status)
+		if transition != "" {
+			matched = t.ID == transition || strings.EqualFold(t.Name, transition)
+		}
+		if matched {
+			if r, err = c.client.Issue.DoTransitionWithContext(ctx, issueID, t.ID); err != nil {
+				err = jira.NewJiraError(r, err)
+				span.RecordError(err)
+				return err
+			}
+			span.AddEvent("issue has been transitioned", trace.WithAttributes(
+				attribute.Key("transition").String(t.Name),
+			))
+			return nil
+		}
+	}
+
+	err = fmt.Errorf("transition %q to status %q is not available for issue %s", transition, status, issueID)
+	span.RecordError(err)
+	return err
+}
+
+func (c *jiraClient) CheckCreateMeta(ctx context.Context, issueType string, fields []string) error {
+	ctx, span := otel.Tracer("jira client").Start(ctx, "CheckCreateMeta")
+	defer span.End()
+	span.SetAttributes(
+		attribute.Key("project").String(c.project),
+		attribute.Key("issue type").String(issueType),
+	)
+
+	meta, r, err := c.client.Issue.GetCreateMetaWithContext(ctx, c.project)
+	if err != nil {
+		err = jira.NewJiraError(r, err)
+		span.RecordError(err)
+		return err
+	}
+
+	project := meta.GetProjectWithKey(c.project)
+	if project == nil {
+		err = fmt.Errorf("project %s is not available", c.project)
+		span.RecordError(err)
+		return err
+	}
+
+	metaIssueType := project.GetIssueTypeWithName(issueType)
+	if metaIssueType == nil {
+		err = fmt.Errorf("issue type %q is not available in project %s", issueType, c.project)
+		span.RecordError(err)
+		return err
+	}
+
+	for _, field := range fields {
+		if _, ok := metaIssueType.Fields[field]; !ok {
+			err = fmt.Errorf("field %s is not available for issue type %q in project %s", field, issueType, c.project)
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetMyself returns the user the API token belongs to.
+func (c *jiraClient) GetMyself(ctx context.Context) (*jira.User, error) {
+	ctx, span := otel.Tracer("jira client").Start(ctx, "GetMyself")
+	defer span.End()
+
+	user, r, err := c.client.User.GetSelfWithContext(ctx)
+	if err != nil {
+		err = jira.NewJiraError(r, err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (c *jiraClient) GetProject(ctx context.Context) (*jira.Project, error) {
+	ctx, span := otel.Tracer("jira client").Start(ctx, "GetProject")
+	defer span.End()
+	span.SetAttributes(attribute.Key("project").String(c.project))
+
+	project, r, err := c.client.Project.GetWithContext(ctx, c.project)
+	if err != nil {
+		err = jira.NewJiraError(r, err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return project, nil
+}
+
 func (c *jiraClient) FindUserByEmail(ctx context.Context, email string) *jira.User {
 	ctx, span := otel.Tracer("jira client").Start(ctx, "FindUserByEmail")
 	defer span.End()
@@ -116,7 +310,7 @@ func (c *jiraClient) FindUserByEmail(ctx context.Context, email string) *jira.Us
 		attribute.Key("users").StringSlice(usersStr),
 	))
 
-	if len(users) > 1 {
+	if len(users) > 0 {
 		span.AddEvent("user selected", trace.WithAttributes(
 			attribute.Key("user").String(users[0].Name),
 		))
@@ -1,48 +1,309 @@
 package jira
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"x-qdo/jiraclick/pkg/metrics"
 	"x-qdo/jiraclick/pkg/model"
 
 	"github.com/andygrunwald/go-jira"
 )
 
+const resource = "jira"
+
 type ConnectorPool struct {
-	clients map[string]ClientInterface
+	mu            sync.RWMutex
+	clients       map[string]ClientInterface
+	accounts      map[string]model.JiraAccount
+	validated     map[string]bool
+	aliases       map[string]string
+	defaultTenant string
 }
 
+// NewJiraConnector returns the pool with the accounts which are loaded together with the failures of the others.
 func NewJiraConnector(accounts map[string]model.JiraAccount) (*ConnectorPool, error) {
+	pool := new(ConnectorPool)
+
+	return pool, pool.Reload(accounts)
+}
+
+// Reload swaps the accounts of the pool, clients of unchanged accounts are kept, an account which can't be loaded
+// keeps its previous client. Requests in flight finish on the client they've got.
+func (pool *ConnectorPool) Reload(accounts map[string]model.JiraAccount) error {
+	var (
+		failures      []string
+		defaultTenant string
+	)
+
+	pool.mu.RLock()
+	previousAccounts, previousClients, previousValidated := pool.accounts, pool.clients, pool.validated
+	pool.mu.RUnlock()
+
 	clients := make(map[string]ClientInterface)
+	accs := make(map[string]model.JiraAccount)
+	validated := make(map[string]bool)
+	aliases := make(map[string]string)
 
-	for tenant, account := range accounts {
-		tenant = strings.ToLower(tenant)
-		tp := jira.BasicAuthTransport{
-			Username: account.Username,
-			Password: account.APIToken,
-		}
+	tenants := make([]string, 0, len(accounts))
+	for tenant := range accounts {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	for _, name := range tenants {
+		account := accounts[name]
+		tenant := strings.ToLower(name)
+		previous, found := previousClients[tenant]
 
-		client, err := jira.NewClient(tp.Client(), account.BaseURL)
-		if err != nil {
-			return nil, err
+		if found && reflect.DeepEqual(previousAccounts[tenant], account) {
+			clients[tenant] = previous
+			validated[tenant] = previousValidated[tenant]
+		} else if client, err := NewClient(tenant, account); err == nil {
+			clients[tenant] = client
+		} else {
+			failures = append(failures, fmt.Sprintf("tenant %s: %s", tenant, err.Error()))
+			if !found {
+				continue
+			}
+			clients[tenant] = previous
+			validated[tenant] = previousValidated[tenant]
+			account = previousAccounts[tenant]
 		}
+		accs[tenant] = account
 
-		clients[tenant] = &jiraClient{
-			client:  client,
-			project: account.Project,
-			baseURL: account.BaseURL,
+		for _, alias := range account.Aliases {
+			aliases[strings.ToLower(alias)] = tenant
+		}
+		if account.Default {
+			if defaultTenant != "" {
+				failures = append(failures, fmt.Sprintf("tenants %s and %s are both default, %s is used", defaultTenant, tenant, defaultTenant))
+				continue
+			}
+			defaultTenant = tenant
 		}
 	}
 
-	return &ConnectorPool{
-		clients: clients,
-	}, nil
+	pool.mu.Lock()
+	pool.clients = clients
+	pool.accounts = accs
+	pool.validated = validated
+	pool.aliases = aliases
+	pool.defaultTenant = defaultTenant
+	pool.mu.Unlock()
+
+	if len(failures) > 0 {
+		return fmt.Errorf("Jira accounts are misconfigured: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+func (pool *ConnectorPool) GetInstance(tenant string) (ClientInterface, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	account, err := pool.resolve(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return pool.clients[account], nil
+}
+
+func (pool *ConnectorPool) GetAccount(tenant string) (model.JiraAccount, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	account, err := pool.resolve(tenant)
+	if err != nil {
+		return model.JiraAccount{}, err
+	}
+
+	return pool.accounts[account], nil
+}
+
+// GetSyncSettings returns empty settings, which sync nothing, for an unknown tenant.
+func (pool *ConnectorPool) GetSyncSettings(tenant string) model.SyncSettings {
+	account, _ := pool.GetAccount(tenant)
+	return account.Sync
+}
+
+// GetAccounts returns the loaded accounts by tenant.
+func (pool *ConnectorPool) GetAccounts() map[string]model.JiraAccount {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	accounts := make(map[string]model.JiraAccount, len(pool.accounts))
+	for tenant, account := range pool.accounts {
+		accounts[tenant] = account
+	}
+
+	return accounts
+}
+
+// WebhookSecret returns the webhook secret of the tenant or its alias. The default account isn't used for unknown
+// tenants, since a webhook mustn't be accepted on behalf of another tenant.
+func (pool *ConnectorPool) WebhookSecret(tenant string) (string, string, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	tenant = strings.ToLower(tenant)
+	if account, ok := pool.aliases[tenant]; ok {
+		tenant = account
+	}
+	account, ok := pool.accounts[tenant]
+	if !ok {
+		return "", "", &model.UnknownTenantError{Resource: resource, Tenant: tenant}
+	}
+
+	return tenant, account.WebhookSecret, nil
+}
+
+// CheckTenant returns UnknownTenantError when neither an account nor an alias matches the tenant,
+// even though the default account serves it.
+func (pool *ConnectorPool) CheckTenant(tenant string) error {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if _, ok := pool.lookup(tenant); !ok {
+		return &model.UnknownTenantError{Resource: resource, Tenant: strings.ToLower(tenant)}
+	}
+
+	return nil
 }
 
-func (pool *ConnectorPool) GetInstance(tenant string) ClientInterface {
+// lookup returns the account of the tenant or of the alias.
+func (pool *ConnectorPool) lookup(tenant string) (string, bool) {
 	tenant = strings.ToLower(tenant)
-	if _, ok := pool.clients[tenant]; !ok {
-		panic(fmt.Sprintf("tenant %s must be declared in config.yaml file", tenant))
+	if _, ok := pool.clients[tenant]; ok {
+		return tenant, true
+	}
+	if account, ok := pool.aliases[tenant]; ok {
+		return account, true
+	}
+
+	return "", false
+}
+
+// resolve returns the account of the tenant, of the alias or the default account.
+func (pool *ConnectorPool) resolve(tenant string) (string, error) {
+	if account, ok := pool.lookup(tenant); ok {
+		return account, nil
+	}
+	if pool.defaultTenant != "" {
+		metrics.UnknownTenantLookup(resource, metrics.TenantFallback)
+		return pool.defaultTenant, nil
+	}
+	metrics.UnknownTenantLookup(resource, metrics.TenantRejected)
+
+	return "", &model.UnknownTenantError{Resource: resource, Tenant: strings.ToLower(tenant)}
+}
+
+func NewClient(tenant string, account model.JiraAccount) (ClientInterface, error) {
+	tp := jira.BasicAuthTransport{
+		Username:  account.Username,
+		Password:  account.APIToken,
+		Transport: metrics.NewTransport(resource, tenant, http.DefaultTransport),
+	}
+
+	client, err := jira.NewClient(tp.Client(), account.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jiraClient{
+		client:  client,
+		project: account.Project,
+		baseURL: account.BaseURL,
+	}, nil
+}
+
+// RequiredFields returns the fields the account sets on created issues, which the createmeta must allow.
+func RequiredFields(account model.JiraAccount) []string {
+	fields := make([]string, 0, len(account.CustomFields)+3)
+	for field := range account.CustomFields {
+		fields = append(fields, field)
+	}
+	if len(account.Components) > 0 {
+		fields = append(fields, "components")
+	}
+	if len(account.Labels) > 0 {
+		fields = append(fields, "labels")
+	}
+	if account.Priority != "" {
+		fields = append(fields, "priority")
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// IssueTypes returns the default and the configured issue types of the account without duplicates.
+func IssueTypes(account model.JiraAccount) []string {
+	issueTypes := map[string]struct{}{model.DefaultJiraIssueType: {}}
+	for _, issueType := range account.IssueTypes {
+		issueTypes[issueType] = struct{}{}
+	}
+
+	types := make([]string, 0, len(issueTypes))
+	for issueType := range issueTypes {
+		types = append(types, issueType)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// Validate checks configured issue types and fields of the accounts, which are new or changed since the last
+// validation, against the project createmeta. A misconfigured account stays in the pool, so other tenants and
+// the requests the account can still serve aren't affected; it's checked again on the next validation.
+func (pool *ConnectorPool) Validate(ctx context.Context) error {
+	var failures []string
+
+	pool.mu.RLock()
+	accounts, clients := pool.accounts, pool.clients
+	pending := make([]string, 0, len(accounts))
+	for tenant := range accounts {
+		if !pool.validated[tenant] {
+			pending = append(pending, tenant)
+		}
+	}
+	pool.mu.RUnlock()
+
+	for _, tenant := range pending {
+		account := accounts[tenant]
+		valid := true
+		fields := RequiredFields(account)
+		for _, issueType := range IssueTypes(account) {
+			if err := clients[tenant].CheckCreateMeta(ctx, issueType, fields); err != nil {
+				failures = append(failures, fmt.Sprintf("tenant %s: %s", tenant, err.Error()))
+				valid = false
+			}
+		}
+		if valid {
+			pool.markValidated(tenant, clients[tenant])
+		}
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("jira accounts are misconfigured: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// markValidated marks the tenant, unless its client is replaced by a reload during the validation.
+func (pool *ConnectorPool) markValidated(tenant string, client ClientInterface) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.clients[tenant] == client {
+		pool.validated[tenant] = true
 	}
-	return pool.clients[tenant]
 }
@@ -0,0 +1,193 @@
+package jira
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+	"go.opentelemetry.io/otel"
+)
+
+const signaturePrefix = "sha256="
+
+type EventType string
+
+const (
+	IssueUpdated   EventType = "jira:issue_updated"
+	IssueDeleted   EventType = "jira:issue_deleted"
+	CommentCreated EventType = "comment_created"
+)
+
+type WebhookEvent struct {
+	Timestamp int64     `json:"timestamp"`
+	Type      EventType `json:"webhookEvent"`
+	User      User      `json:"user"`
+	Issue     Issue     `json:"issue"`
+	Changelog struct {
+		ID    string          `json:"id"`
+		Items []ChangelogItem `json:"items"`
+	} `json:"changelog"`
+	Comment *Comment `json:"comment,omitempty"`
+}
+
+type Issue struct {
+	ID     string                 `json:"id"`
+	Key    string                 `json:"key"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+type User struct {
+	AccountID    string `json:"accountId,omitempty"`
+	Name         string `json:"name,omitempty"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+	DisplayName  string `json:"displayName"`
+}
+
+type ChangelogItem struct {
+	Field      string `json:"field"`
+	FieldType  string `json:"fieldtype"`
+	From       string `json:"from"`
+	FromString string `json:"fromString"`
+	To         string `json:"to"`
+	ToString   string `json:"toString"`
+}
+
+type Comment struct {
+	ID     string `json:"id"`
+	Body   string `json:"body"`
+	Author User   `json:"author"`
+}
+
+// CheckSignature validates the `X-Hub-Signature` header Jira sends for webhooks
+// registered with a secret.
+func CheckSignature(ctx context.Context, signature, body, secret string) bool {
+	ctx, span := otel.Tracer("jira provider").Start(ctx, "CheckSignature")
+	defer span.End()
+
+	if secret == "" || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	hash := hmac.New(sha256.New, []byte(secret))
+	if _, err := hash.Write([]byte(body)); err != nil {
+		span.RecordError(err)
+		return false
+	}
+
+	expected := hex.EncodeToString(hash.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(strings.TrimPrefix(signature, signaturePrefix)))
+}
+
+// CheckJWT validates a JWT signed with the shared secret, as Jira Connect apps send it. The qsh claim must match
+// the request, otherwise a captured token would authenticate any body.
+func CheckJWT(ctx context.Context, token, secret, method, path string, query url.Values) bool {
+	ctx, span := otel.Tracer("jira provider").Start(ctx, "CheckJWT")
+	defer span.End()
+
+	if secret == "" || token == "" {
+		return false
+	}
+
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return false
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	qsh, _ := claims["qsh"].(string)
+	if !hmac.Equal([]byte(qsh), []byte(QueryStringHash(method, path, query))) {
+		span.AddEvent("qsh doesn't match the request")
+		return false
+	}
+
+	return parsed.Valid
+}
+
+// QueryStringHash returns the qsh claim of the request as Atlassian Connect computes it:
+// the SHA-256 of the canonical method, path and query without the jwt parameter.
+func QueryStringHash(method, path string, query url.Values) string {
+	if path == "" {
+		path = "/"
+	} else if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	path = strings.ReplaceAll(path, "&", "%26")
+
+	values := make(map[string]string, len(query))
+	keys := make([]string, 0, len(query))
+	for key, vs := range query {
+		if key == "jwt" {
+			continue
+		}
+		encoded := make([]string, 0, len(vs))
+		for _, value := range vs {
+			encoded = append(encoded, percentEncode(value))
+		}
+		sort.Strings(encoded)
+		key = percentEncode(key)
+		keys = append(keys, key)
+		values[key] = strings.Join(encoded, ",")
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, key+"="+values[key])
+	}
+
+	hash := sha256.Sum256([]byte(strings.ToUpper(method) + "&" + path + "&" + strings.Join(params, "&")))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// percentEncode encodes by RFC 3986, which Atlassian Connect uses for the canonical query.
+func percentEncode(value string) string {
+	encoded := url.QueryEscape(value)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+
+	return strings.ReplaceAll(encoded, "%7E", "~")
+}
+
+func ParseEvent(ctx context.Context, body string) (*WebhookEvent, error) {
+	ctx, span := otel.Tracer("jira provider").Start(ctx, "ParseEvent")
+	defer span.End()
+	e := new(WebhookEvent)
+	if err := json.Unmarshal([]byte(body), e); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	switch e.Type {
+	case IssueUpdated:
+		if len(e.Changelog.Items) == 0 {
+			return nil, nil
+		}
+	case CommentCreated:
+		if e.Comment == nil {
+			return nil, nil
+		}
+	case IssueDeleted:
+	default:
+		return nil, nil
+	}
+
+	return e, nil
+}
@@ -4,17 +4,27 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-pg/pg/extra/pgotel/v10"
 	"github.com/go-pg/pg/v10"
 	"github.com/go-pg/pg/v10/orm"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
 	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/metrics"
 	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/secret"
 )
 
+const accountsChangedChannel = "accounts_changed"
+
 type postgresDB struct {
 	conn         *pg.DB
+	mu           sync.RWMutex
 	transactions map[context.Context]*pg.Tx
+	keyring      *secret.Keyring
 }
 
 type queryFunc func(query *orm.Query)
@@ -22,6 +32,12 @@ type queryFunc func(query *orm.Query)
 func NewPostgres(cfg *config.Config) (*postgresDB, error) {
 	postgresDB := new(postgresDB)
 
+	keyring, err := secret.NewKeyring(cfg)
+	if err != nil {
+		return nil, err
+	}
+	postgresDB.keyring = keyring
+
 	opt, err := pg.ParseURL(cfg.Postgres.URL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Postgres: %s", err.Error())
@@ -51,36 +67,53 @@ func (db *postgresDB) Begin(ctx context.Context) error {
 		return err
 	}
 
+	db.mu.Lock()
 	db.transactions[ctx] = tx
+	db.mu.Unlock()
 
 	return nil
 }
 
 func (db *postgresDB) Commit(ctx context.Context) error {
-	if tx, found := db.transactions[ctx]; found {
+	if tx, found := db.getTransaction(ctx); found {
 		err := tx.Commit()
 		if err != nil {
 			return err
 		}
-		delete(db.transactions, ctx)
+		db.deleteTransaction(ctx)
 		return nil
 	}
 	return fmt.Errorf("transaction for context is not found")
 }
 
 func (db *postgresDB) Rollback(ctx context.Context) error {
-	if tx, found := db.transactions[ctx]; found {
+	if tx, found := db.getTransaction(ctx); found {
 		err := tx.Rollback()
 		if err != nil {
 			return err
 		}
-		delete(db.transactions, ctx)
+		db.deleteTransaction(ctx)
 	}
 	return nil
 }
 
+// transactions are shared by consumers running concurrently, so the map is guarded.
+func (db *postgresDB) getTransaction(ctx context.Context) (*pg.Tx, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	tx, found := db.transactions[ctx]
+	return tx, found
+}
+
+func (db *postgresDB) deleteTransaction(ctx context.Context) {
+	db.mu.Lock()
+	delete(db.transactions, ctx)
+	db.mu.Unlock()
+}
+
 func (db *postgresDB) getConnection(ctx context.Context) orm.DB {
-	if tx, found := db.transactions[ctx]; found {
+	if tx, found := db.getTransaction(ctx); found {
 		return tx
 	}
 
@@ -157,7 +190,7 @@ func (db *postgresDB) GetJiraAccounts(ctx context.Context) (map[string]model.Jir
 	results := make(map[string]model.JiraAccount)
 	query := db.getConnection(ctx).Model(&accounts)
 
-	query.Where("resource = ?", "jira")
+	query.Where("resource = ?", "jira").Where("delete_at IS NULL")
 
 	if err := query.Select(); err != nil {
 		return nil, err
@@ -165,11 +198,11 @@ func (db *postgresDB) GetJiraAccounts(ctx context.Context) (map[string]model.Jir
 
 	for _, account := range accounts {
 		var jiraAcc model.JiraAccount
-		if props, err := json.Marshal(account.Props); err == nil {
-			if err := json.Unmarshal(props, &jiraAcc); err == nil {
-				results[account.SlackChannel] = jiraAcc
-			}
+		if err := db.decodeAccount(account, &jiraAcc); err != nil {
+			skipAccount(account, err)
+			continue
 		}
+		results[account.SlackChannel] = jiraAcc
 	}
 
 	return results, nil
@@ -180,7 +213,7 @@ func (db *postgresDB) GetClickUpAccounts(ctx context.Context) (map[string]model.
 	results := make(map[string]model.ClickUpAccount)
 	query := db.getConnection(ctx).Model(&accounts)
 
-	query.Where("resource = ?", "clickup")
+	query.Where("resource = ?", "clickup").Where("delete_at IS NULL")
 
 	if err := query.Select(); err != nil {
 		return nil, err
@@ -188,12 +221,556 @@ func (db *postgresDB) GetClickUpAccounts(ctx context.Context) (map[string]model.
 
 	for _, account := range accounts {
 		var clickAcc model.ClickUpAccount
-		if props, err := json.Marshal(account.Props); err == nil {
-			if err := json.Unmarshal(props, &clickAcc); err == nil {
-				results[account.SlackChannel] = clickAcc
-			}
+		if err := db.decodeAccount(account, &clickAcc); err != nil {
+			skipAccount(account, err)
+			continue
 		}
+		results[account.SlackChannel] = clickAcc
 	}
 
 	return results, nil
 }
+
+// decodeAccount decrypts secret props of the account and decodes them into the typed account.
+func (db *postgresDB) decodeAccount(account model.Account, typed interface{}) error {
+	decrypted, err := db.decryptProps(account.Resource, account.SlackChannel, account.Props)
+	if err != nil {
+		return err
+	}
+	props, err := json.Marshal(decrypted)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(props, typed)
+}
+
+// skipAccount reports an account which can't be loaded, so the other accounts keep working.
+func skipAccount(account model.Account, err error) {
+	metrics.AccountSkipped(account.Resource)
+	logrus.Errorf("%s account for %s is skipped: %s", account.Resource, account.SlackChannel, err.Error())
+}
+
+func (db *postgresDB) UpdateAccountProps(
+	ctx context.Context,
+	resource, slackChannel string,
+	props map[string]interface{},
+) error {
+	props, err := db.encryptProps(resource, slackChannel, props)
+	if err != nil {
+		return err
+	}
+
+	res, err := db.getConnection(ctx).Model((*model.Account)(nil)).
+		Set("props = coalesce(props, '{}'::jsonb) || ?::jsonb", props).
+		Set("update_at = now()").
+		Where("resource = ?", resource).
+		Where("slack_channel = ?", slackChannel).
+		Where("delete_at IS NULL").
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%s account for %s is not found", resource, slackChannel)
+	}
+
+	return nil
+}
+
+// ListAccounts returns all accounts including disabled ones, their secret props are decrypted.
+func (db *postgresDB) ListAccounts(ctx context.Context) ([]model.Account, error) {
+	var accounts []model.Account
+	query := db.getConnection(ctx).Model(&accounts)
+
+	query.Order("resource", "slack_channel", "id")
+
+	if err := query.Select(); err != nil {
+		return nil, err
+	}
+
+	for i := range accounts {
+		props, err := db.decryptProps(accounts[i].Resource, accounts[i].SlackChannel, accounts[i].Props)
+		if err != nil {
+			return nil, fmt.Errorf("%s account for %s: %w", accounts[i].Resource, accounts[i].SlackChannel, err)
+		}
+		accounts[i].Props = props
+	}
+
+	return accounts, nil
+}
+
+func (db *postgresDB) CreateAccount(ctx context.Context, account *model.Account) error {
+	props, err := db.encryptProps(account.Resource, account.SlackChannel, account.GetProps())
+	if err != nil {
+		return err
+	}
+
+	encrypted := *account
+	encrypted.Props = props
+	if err = db.modelInsert(ctx, &encrypted); err != nil {
+		return err
+	}
+	account.Id = encrypted.Id
+	account.CreateAt = encrypted.CreateAt
+
+	return nil
+}
+
+func (db *postgresDB) DisableAccount(ctx context.Context, resource, slackChannel string) error {
+	res, err := db.getConnection(ctx).Model((*model.Account)(nil)).
+		Set("delete_at = now()").
+		Where("resource = ?", resource).
+		Where("slack_channel = ?", slackChannel).
+		Where("delete_at IS NULL").
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%s account for %s is not found", resource, slackChannel)
+	}
+
+	return nil
+}
+
+// ReencryptAccounts encrypts secret props of all accounts with the active key and returns the number
+// of updated accounts. Plain secrets are encrypted too.
+func (db *postgresDB) ReencryptAccounts(ctx context.Context) (int, error) {
+	var updated int
+
+	if !db.keyring.Enabled() {
+		return 0, secret.ErrNoActiveKey
+	}
+
+	err := db.conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		var accounts []model.Account
+
+		if err := tx.Model(&accounts).For("UPDATE").Select(); err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			props, ok := account.Props.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			changed := false
+			for _, key := range model.SecretProps[account.Resource] {
+				if _, ok := props[key]; !ok {
+					continue
+				}
+				additionalData := secret.AdditionalData(account.Resource, account.SlackChannel, key)
+				sealed, err := mapSecret(props[key], func(value string) (string, error) {
+					if secret.IsCurrent(value, db.keyring.ActiveKeyID()) {
+						return value, nil
+					}
+					plain, err := db.keyring.Decrypt(value, additionalData)
+					if err != nil {
+						return "", err
+					}
+					changed = true
+					return db.keyring.Encrypt(plain, additionalData)
+				})
+				if err != nil {
+					return fmt.Errorf("%s account for %s: %w", account.Resource, account.SlackChannel, err)
+				}
+				props[key] = sealed
+			}
+			if !changed {
+				continue
+			}
+
+			_, err := tx.Model((*model.Account)(nil)).
+				Set("props = ?::jsonb", props).
+				Set("update_at = now()").
+				Where("id = ?", account.Id).
+				Update()
+			if err != nil {
+				return err
+			}
+			updated++
+		}
+
+		return nil
+	})
+
+	return updated, err
+}
+
+func (db *postgresDB) decryptProps(resource, slackChannel string, props interface{}) (interface{}, error) {
+	values, ok := props.(map[string]interface{})
+	if !ok {
+		return props, nil
+	}
+
+	for _, key := range model.SecretProps[resource] {
+		if _, ok := values[key]; !ok {
+			continue
+		}
+		additionalData := secret.AdditionalData(resource, slackChannel, key)
+		plain, err := mapSecret(values[key], func(value string) (string, error) {
+			return db.keyring.Decrypt(value, additionalData)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s can't be decrypted: %w", key, err)
+		}
+		values[key] = plain
+	}
+
+	return values, nil
+}
+
+// mapSecret applies f to a secret prop, which is a string or a list of strings; empty strings are kept as is.
+func mapSecret(value interface{}, f func(string) (string, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return v, nil
+		}
+		return f(v)
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			mapped, err := mapSecret(item, f)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = mapped
+		}
+		return values, nil
+	}
+
+	return value, nil
+}
+
+func (db *postgresDB) encryptProps(
+	resource, slackChannel string,
+	props map[string]interface{},
+) (map[string]interface{}, error) {
+	encrypted := make(map[string]interface{}, len(props))
+	for key, value := range props {
+		encrypted[key] = value
+	}
+
+	for _, key := range model.SecretProps[resource] {
+		if _, ok := encrypted[key]; !ok {
+			continue
+		}
+		additionalData := secret.AdditionalData(resource, slackChannel, key)
+		sealed, err := mapSecret(encrypted[key], func(value string) (string, error) {
+			if secret.IsEncrypted(value) {
+				return value, nil
+			}
+			return db.keyring.Encrypt(value, additionalData)
+		})
+		if err != nil {
+			return nil, err
+		}
+		encrypted[key] = sealed
+	}
+
+	return encrypted, nil
+}
+
+// CreateTaskLink merges the link into the one of the same Slack thread. A link without a thread is merged
+// into the one of the same request, or else into the one with the same ClickUp task or Jira issue.
+func (db *postgresDB) CreateTaskLink(ctx context.Context, link *model.TaskLink) error {
+	switch {
+	case link.SlackTS != "":
+		return db.upsertTaskLink(ctx, link, "(slack_channel, slack_ts) DO UPDATE")
+	case link.RequestID != "":
+		return db.upsertTaskLink(ctx, link, "(slack_channel, request_id) WHERE slack_ts IS NULL DO UPDATE")
+	case link.ClickupID == "" && link.JiraID == "":
+		return db.modelInsert(ctx, link)
+	}
+
+	existing, err := db.getTaskLink(ctx, func(query *orm.Query) {
+		query.Where("slack_channel = ?", link.SlackChannel).
+			Where("slack_ts IS NULL").
+			WhereGroup(func(query *orm.Query) (*orm.Query, error) {
+				if link.ClickupID != "" {
+					query.WhereOr("clickup_id = ?", link.ClickupID)
+				}
+				if link.JiraID != "" {
+					query.WhereOr("jira_id = ?", link.JiraID)
+				}
+				return query, nil
+			})
+	})
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return db.modelInsert(ctx, link)
+	}
+
+	_, err = db.getConnection(ctx).Model(link).
+		Set("clickup_id = COALESCE(NULLIF(?, ''), clickup_id)", link.ClickupID).
+		Set("jira_id = COALESCE(NULLIF(?, ''), jira_id)", link.JiraID).
+		Set("update_at = now()").
+		Where("id = ?", existing.Id).
+		Returning("*").
+		Update()
+
+	return err
+}
+
+func (db *postgresDB) upsertTaskLink(ctx context.Context, link *model.TaskLink, conflict string) error {
+	_, err := db.getConnection(ctx).Model(link).
+		OnConflict(conflict).
+		Set("clickup_id = COALESCE(EXCLUDED.clickup_id, task_link.clickup_id)").
+		Set("jira_id = COALESCE(EXCLUDED.jira_id, task_link.jira_id)").
+		Set("update_at = now()").
+		Returning("*").
+		Insert()
+
+	return err
+}
+
+func (db *postgresDB) GetTaskLinkByClickUpID(ctx context.Context, clickupID string) (*model.TaskLink, error) {
+	return db.getTaskLink(ctx, func(query *orm.Query) {
+		query.Where("clickup_id = ?", clickupID)
+	})
+}
+
+func (db *postgresDB) GetTaskLinkByJiraID(ctx context.Context, jiraID string) (*model.TaskLink, error) {
+	return db.getTaskLink(ctx, func(query *orm.Query) {
+		query.Where("jira_id = ?", jiraID)
+	})
+}
+
+func (db *postgresDB) GetTaskLinkBySlackThread(ctx context.Context, slackChannel, slackTS string) (*model.TaskLink, error) {
+	return db.getTaskLink(ctx, func(query *orm.Query) {
+		query.Where("slack_channel = ?", slackChannel).Where("slack_ts = ?", slackTS)
+	})
+}
+
+func (db *postgresDB) getTaskLink(ctx context.Context, queryFunc queryFunc) (*model.TaskLink, error) {
+	link := new(model.TaskLink)
+	query := db.getConnection(ctx).Model(link)
+
+	queryFunc(query)
+
+	if err := query.Order("id DESC").Limit(1).Select(); err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (db *postgresDB) CreateSyncMark(ctx context.Context, mark *model.SyncMark) error {
+	return db.modelInsert(ctx, mark)
+}
+
+func (db *postgresDB) ConsumeSyncMark(ctx context.Context, mark *model.SyncMark, ttl time.Duration) (bool, error) {
+	res, err := db.getConnection(ctx).Model((*model.SyncMark)(nil)).
+		Where("id = (?)", db.getConnection(ctx).Model((*model.SyncMark)(nil)).
+			Column("id").
+			Where("link_id = ?", mark.LinkID).
+			Where("target = ?", mark.Target).
+			Where("field = ?", mark.Field).
+			Where("value_hash = ?", mark.ValueHash).
+			Where("create_at > now() - make_interval(secs => ?)", ttl.Seconds()).
+			Order("id").
+			Limit(1)).
+		Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (db *postgresDB) GetStatusMappings(ctx context.Context, slackChannel string) (model.StatusMappings, error) {
+	var mappings model.StatusMappings
+	query := db.getConnection(ctx).Model(&mappings)
+
+	query.Where("lower(slack_channel) = lower(?)", slackChannel).Order("id")
+
+	if err := query.Select(); err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
+}
+
+func (db *postgresDB) UpsertStatusMapping(ctx context.Context, mapping *model.StatusMapping) error {
+	_, err := db.getConnection(ctx).Model(mapping).
+		OnConflict("(slack_channel, lower(clickup_status)) DO UPDATE").
+		Set("clickup_status = EXCLUDED.clickup_status").
+		Set("jira_status = EXCLUDED.jira_status").
+		Set("jira_transition = EXCLUDED.jira_transition").
+		Set("update_at = now()").
+		Returning("*").
+		Insert()
+
+	return err
+}
+
+func (db *postgresDB) DeleteStatusMapping(ctx context.Context, slackChannel, clickupStatus string) (bool, error) {
+	res, err := db.getConnection(ctx).Model((*model.StatusMapping)(nil)).
+		Where("lower(slack_channel) = lower(?)", slackChannel).
+		Where("lower(clickup_status) = lower(?)", clickupStatus).
+		Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (db *postgresDB) CreateProcessedMessage(ctx context.Context, message *model.ProcessedMessage) error {
+	_, err := db.getConnection(ctx).Model(message).
+		OnConflict("(message_id) DO NOTHING").
+		Insert()
+
+	return err
+}
+
+func (db *postgresDB) GetProcessedMessage(ctx context.Context, messageID string) (*model.ProcessedMessage, error) {
+	message := &model.ProcessedMessage{MessageID: messageID}
+
+	if err := db.modelGet(ctx, message, nil); err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (db *postgresDB) DeleteProcessedMessages(ctx context.Context, ttl time.Duration) (int, error) {
+	res, err := db.getConnection(ctx).Model((*model.ProcessedMessage)(nil)).
+		Where("create_at <= now() - make_interval(secs => ?)", ttl.Seconds()).
+		Delete()
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (db *postgresDB) ClaimWebhookHistoryItem(
+	ctx context.Context,
+	item *model.WebhookHistoryItem,
+	ttl time.Duration,
+) (bool, error) {
+	// an expired item is claimed again, as if it was deleted already
+	res, err := db.getConnection(ctx).Model(item).
+		OnConflict("(id) DO UPDATE").
+		Set("task_id = EXCLUDED.task_id").
+		Set("create_at = now()").
+		Where("?TableAlias.create_at <= now() - make_interval(secs => ?)", ttl.Seconds()).
+		Insert()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (db *postgresDB) ReleaseWebhookHistoryItems(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := db.getConnection(ctx).Model((*model.WebhookHistoryItem)(nil)).
+		Where("id IN (?)", pg.In(ids)).
+		Delete()
+
+	return err
+}
+
+func (db *postgresDB) DeleteWebhookHistoryItems(ctx context.Context, ttl time.Duration) (int, error) {
+	res, err := db.getConnection(ctx).Model((*model.WebhookHistoryItem)(nil)).
+		Where("create_at <= now() - make_interval(secs => ?)", ttl.Seconds()).
+		Delete()
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (db *postgresDB) ApplyTaskFieldChange(ctx context.Context, change *model.TaskFieldChange) (bool, error) {
+	res, err := db.getConnection(ctx).Model(change).
+		OnConflict("(task_id, field) DO UPDATE").
+		Set("history_item_id = EXCLUDED.history_item_id").
+		Set("changed_at = EXCLUDED.changed_at").
+		Where("?TableAlias.changed_at <= EXCLUDED.changed_at").
+		Insert()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (db *postgresDB) CreateOutboxEvent(ctx context.Context, event *model.OutboxEvent) error {
+	return db.modelInsert(ctx, event)
+}
+
+func (db *postgresDB) ClaimPendingOutboxEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	query := db.getConnection(ctx).Model(&events)
+
+	query.Where("sent_at IS NULL").Order("id").Limit(limit).For("UPDATE SKIP LOCKED")
+
+	if err := query.Select(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (db *postgresDB) MarkOutboxEventSent(ctx context.Context, id int) error {
+	_, err := db.getConnection(ctx).Model((*model.OutboxEvent)(nil)).
+		Set("sent_at = now()").
+		Where("id = ?", id).
+		Update()
+
+	return err
+}
+
+func (db *postgresDB) DeleteSentOutboxEvents(ctx context.Context, ttl time.Duration) (int, error) {
+	res, err := db.getConnection(ctx).Model((*model.OutboxEvent)(nil)).
+		Where("sent_at <= now() - make_interval(secs => ?)", ttl.Seconds()).
+		Delete()
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// WatchAccounts signals on every change of the accounts table till ctx is done.
+// Notifications coming while the previous one isn't handled yet are merged.
+func (db *postgresDB) WatchAccounts(ctx context.Context) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	ln := db.conn.Listen(ctx, accountsChangedChannel)
+
+	go func() {
+		defer ln.Close()
+		notifications := ln.Channel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-notifications:
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changes
+}
@@ -2,17 +2,22 @@ package publisher
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/astreter/amqpwrapper/v2"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 
 	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/metrics"
 	"x-qdo/jiraclick/pkg/model"
 )
 
 type EventPublisher struct {
 	queueProvider *amqpwrapper.RabbitChannel
+	outbox        contract.Storage
 }
 
 func NewEventPublisher(queueProvider *amqpwrapper.RabbitChannel) (*EventPublisher, error) {
@@ -25,9 +30,51 @@ func NewEventPublisher(queueProvider *amqpwrapper.RabbitChannel) (*EventPublishe
 	}, nil
 }
 
+// NewOutboxEventPublisher returns a publisher which stores events in the outbox, so they are committed
+// together with the transaction of ctx and sent by OutboxRelay.
+func NewOutboxEventPublisher(queueProvider *amqpwrapper.RabbitChannel, db contract.Storage) (*EventPublisher, error) {
+	p, err := NewEventPublisher(queueProvider)
+	if err != nil {
+		return nil, err
+	}
+	p.outbox = db
+
+	return p, nil
+}
+
+func (p *EventPublisher) publish(ctx context.Context, payload interface{}, routingKey string) error {
+	err := p.send(ctx, payload, routingKey)
+	if err != nil {
+		metrics.PublishFailed(routingKey)
+	}
+
+	return err
+}
+
+func (p *EventPublisher) send(ctx context.Context, payload interface{}, routingKey string) error {
+	if p.outbox == nil {
+		return p.queueProvider.Publish(ctx, payload, contract.BRPEventsExchange, routingKey)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	traceHeaders := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceHeaders)
+
+	return p.outbox.CreateOutboxEvent(ctx, &model.OutboxEvent{
+		Exchange:     contract.BRPEventsExchange,
+		RoutingKey:   routingKey,
+		Payload:      body,
+		TraceHeaders: traceHeaders,
+	})
+}
+
 func (p *EventPublisher) ClickUpTaskCreated(ctx context.Context, payload model.TaskPayload) error {
 	routingKey := fmt.Sprintf(string(contract.TaskCreatedClickUpEvent), payload.SlackChannel)
-	if err := p.queueProvider.Publish(ctx, payload, contract.BRPEventsExchange, routingKey); err != nil {
+	if err := p.publish(ctx, payload, routingKey); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("failed to send a %s to events queue", routingKey))
 	}
 
@@ -36,7 +83,16 @@ func (p *EventPublisher) ClickUpTaskCreated(ctx context.Context, payload model.T
 
 func (p *EventPublisher) JiraTaskCreated(ctx context.Context, payload model.TaskPayload) error {
 	routingKey := fmt.Sprintf(string(contract.TaskCreatedJiraEvent), payload.SlackChannel)
-	if err := p.queueProvider.Publish(ctx, payload, contract.BRPEventsExchange, routingKey); err != nil {
+	if err := p.publish(ctx, payload, routingKey); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to send a %s to events queue", routingKey))
+	}
+
+	return nil
+}
+
+func (p *EventPublisher) JiraTaskUpdated(ctx context.Context, payload model.TaskPayload) error {
+	routingKey := fmt.Sprintf(string(contract.TaskUpdatedJiraEvent), payload.SlackChannel)
+	if err := p.publish(ctx, payload, routingKey); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("failed to send a %s to events queue", routingKey))
 	}
 
@@ -45,7 +101,43 @@ func (p *EventPublisher) JiraTaskCreated(ctx context.Context, payload model.Task
 
 func (p *EventPublisher) ClickUpTaskUpdated(ctx context.Context, payload model.TaskChanges, slackChannel string) error {
 	routingKey := fmt.Sprintf(string(contract.TaskUpdatedClickUpEvent), slackChannel)
-	if err := p.queueProvider.Publish(ctx, payload, contract.BRPEventsExchange, routingKey); err != nil {
+	if err := p.publish(ctx, payload, routingKey); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to send a %s to events queue", routingKey))
+	}
+
+	return nil
+}
+
+func (p *EventPublisher) JiraTaskChanged(ctx context.Context, payload model.TaskChanges, slackChannel string) error {
+	routingKey := fmt.Sprintf(string(contract.TaskUpdatedJiraEvent), slackChannel)
+	if err := p.publish(ctx, payload, routingKey); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to send a %s to events queue", routingKey))
+	}
+
+	return nil
+}
+
+func (p *EventPublisher) TaskSyncFailed(ctx context.Context, payload model.TaskSyncFailure, slackChannel string) error {
+	routingKey := fmt.Sprintf(string(contract.TaskSyncFailedEvent), slackChannel)
+	if err := p.publish(ctx, payload, routingKey); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to send a %s to events queue", routingKey))
+	}
+
+	return nil
+}
+
+func (p *EventPublisher) TaskFailed(ctx context.Context, payload model.TaskFailure, slackChannel string) error {
+	routingKey := fmt.Sprintf(string(contract.TaskFailedEvent), slackChannel)
+	if err := p.publish(ctx, payload, routingKey); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to send a %s to events queue", routingKey))
+	}
+
+	return nil
+}
+
+func (p *EventPublisher) UnknownTenant(ctx context.Context, payload model.UnknownTenant, slackChannel string) error {
+	routingKey := fmt.Sprintf(string(contract.UnknownTenantEvent), slackChannel)
+	if err := p.publish(ctx, payload, routingKey); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("failed to send a %s to events queue", routingKey))
 	}
 
@@ -0,0 +1,122 @@
+package publisher
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/astreter/amqpwrapper/v2"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/metrics"
+	"x-qdo/jiraclick/pkg/model"
+)
+
+const (
+	outboxRelayInterval = time.Second
+	outboxBatchSize     = 100
+)
+
+// OutboxRelay sends pending outbox events in order and marks them sent once RabbitMQ confirms them.
+// An event is sent again if marking fails, so consumers get it at least once.
+type OutboxRelay struct {
+	queueProvider *amqpwrapper.RabbitChannel
+	db            contract.Storage
+}
+
+func NewOutboxRelay(queueProvider *amqpwrapper.RabbitChannel, db contract.Storage) *OutboxRelay {
+	return &OutboxRelay{
+		queueProvider: queueProvider,
+		db:            db,
+	}
+}
+
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(outboxRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.drain(ctx); err != nil {
+				logrus.Errorf("outbox relay: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (r *OutboxRelay) drain(ctx context.Context) error {
+	for {
+		sent, err := r.drainBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if sent < outboxBatchSize {
+			return nil
+		}
+	}
+}
+
+// drainBatch claims a batch in a transaction, so relays of other workers skip it instead of sending it again.
+// Events sent before a failure are committed as sent.
+func (r *OutboxRelay) drainBatch(ctx context.Context) (int, error) {
+	if err := r.db.Begin(ctx); err != nil {
+		return 0, err
+	}
+
+	events, err := r.db.ClaimPendingOutboxEvents(ctx, outboxBatchSize)
+	if err != nil {
+		_ = r.db.Rollback(ctx)
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err = r.send(ctx, event); err == nil {
+			err = r.db.MarkOutboxEventSent(ctx, event.Id)
+		}
+		if err != nil {
+			if commitErr := r.db.Commit(ctx); commitErr != nil {
+				_ = r.db.Rollback(ctx)
+			}
+			return 0, err
+		}
+	}
+
+	return len(events), r.db.Commit(ctx)
+}
+
+// send continues the trace the event is stored in, so BRP gets it in the message headers.
+func (r *OutboxRelay) send(ctx context.Context, event model.OutboxEvent) error {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(event.TraceHeaders))
+	ctx, span := otel.Tracer("outbox relay").Start(ctx, "send")
+	defer span.End()
+
+	err := r.queueProvider.Publish(ctx, event.Payload, event.Exchange, event.RoutingKey, eventHeaders(event)...)
+	if err != nil {
+		metrics.PublishFailed(event.RoutingKey)
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+func eventHeaders(event model.OutboxEvent) []amqpwrapper.Header {
+	keys := make([]string, 0, len(event.Headers))
+	for key := range event.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	headers := make([]amqpwrapper.Header, 0, len(keys))
+	for _, key := range keys {
+		headers = append(headers, amqpwrapper.Header{Key: key, Value: event.Headers[key]})
+	}
+
+	return headers
+}
@@ -0,0 +1,70 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/astreter/amqpwrapper/v2"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"x-qdo/jiraclick/pkg/config"
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/metrics"
+	"x-qdo/jiraclick/pkg/model"
+)
+
+// WebhookPublisher enqueues accepted webhooks for the worker.
+type WebhookPublisher struct {
+	queueProvider *amqpwrapper.RabbitChannel
+}
+
+// NewWebhookPublisher declares the webhooks queue as well, so webhooks received before the worker has ever
+// started aren't dropped by the exchange.
+func NewWebhookPublisher(cfg *config.Config, queueProvider *amqpwrapper.RabbitChannel) (*WebhookPublisher, error) {
+	if err := queueProvider.DefineExchange(contract.WebhooksExchange, false); err != nil {
+		return nil, err
+	}
+	if err := declareWebhooksQueue(cfg.GetRabbitMQURL()); err != nil {
+		return nil, err
+	}
+
+	return &WebhookPublisher{
+		queueProvider: queueProvider,
+	}, nil
+}
+
+// declareWebhooksQueue declares the queue the way amqpwrapper does it for the consumer.
+func declareWebhooksQueue(url string) error {
+	name := string(contract.Webhooks)
+
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return fmt.Errorf("RabbitMQ: failed to connect for queue declaration: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("RabbitMQ: failed to open a channel for queue declaration: %w", err)
+	}
+	defer ch.Close()
+
+	if _, err = ch.QueueDeclare(name, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("RabbitMQ: failed to declare a queue %s: %w", name, err)
+	}
+	if err = ch.QueueBind(name, name, contract.WebhooksExchange, false, nil); err != nil {
+		return fmt.Errorf("RabbitMQ: failed to bind a queue %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Publish returns once RabbitMQ confirms the webhook.
+func (p *WebhookPublisher) Publish(ctx context.Context, webhook model.InboundWebhook) error {
+	err := p.queueProvider.Publish(ctx, webhook, contract.WebhooksExchange, string(contract.Webhooks))
+	if err != nil {
+		metrics.PublishFailed(string(contract.Webhooks))
+	}
+
+	return err
+}
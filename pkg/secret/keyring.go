@@ -0,0 +1,242 @@
+package secret
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"x-qdo/jiraclick/pkg/config"
+)
+
+const (
+	// prefix marks encrypted values: enc:v2:<key ID>:<wrapped data key>:<sealed value>, base64 encoded.
+	// Both the data key and the value are sealed with the additional data of the value.
+	prefix = "enc:v2:"
+	// prefixV1 marks values sealed without additional data; they are decrypted until they're re-encrypted.
+	prefixV1 = "enc:v1:"
+)
+
+var ErrNoActiveKey = errors.New("encryption key is not configured")
+
+// Keyring encrypts values with a random data key, which is wrapped by the active master key.
+// Keys of previous rotations are kept to decrypt values which are not re-encrypted yet.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// NewKeyring loads `id:base64 key` pairs separated by commas from encryption.keys and by new lines
+// from encryption.keyfile. A keyring without keys keeps values as is.
+func NewKeyring(cfg *config.Config) (*Keyring, error) {
+	k := &Keyring{
+		active: cfg.Encryption.Active,
+		keys:   make(map[string][]byte),
+	}
+
+	entries := strings.Split(cfg.Encryption.Keys, ",")
+	if cfg.Encryption.KeyFile != "" {
+		fileEntries, err := readKeyFile(cfg.Encryption.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("encryption key must be formatted as `id:base64 key`")
+		}
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", parts[0], err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption key %s must be 32 bytes long", parts[0])
+		}
+		k.keys[parts[0]] = key
+	}
+
+	if k.active == "" && len(k.keys) == 1 {
+		for id := range k.keys {
+			k.active = id
+		}
+	}
+	if _, ok := k.keys[k.active]; k.active != "" && !ok {
+		return nil, fmt.Errorf("active encryption key %s is not found", k.active)
+	}
+
+	return k, nil
+}
+
+func readKeyFile(path string) ([]string, error) {
+	var entries []string
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key file can't be read: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// Enabled reports whether new values are encrypted.
+func (k *Keyring) Enabled() bool {
+	return k.active != ""
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix) || strings.HasPrefix(value, prefixV1)
+}
+
+// IsCurrent reports whether the value is encrypted by the key in the current format.
+func IsCurrent(value, keyID string) bool {
+	return strings.HasPrefix(value, prefix) && KeyID(value) == keyID
+}
+
+// AdditionalData binds a sealed value to the prop of the account it's stored in, so it can't be moved to another one.
+func AdditionalData(resource, slackChannel, prop string) []byte {
+	return []byte(resource + "/" + slackChannel + "/" + prop)
+}
+
+// Encrypt seals the value with the active key and the additional data, the value is returned as is
+// when encryption is disabled.
+func (k *Keyring) Encrypt(value string, additionalData []byte) (string, error) {
+	if !k.Enabled() {
+		return value, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(k.keys[k.active], dataKey, additionalData)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, []byte(value), additionalData)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + k.active + ":" +
+		base64.StdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens an encrypted value with the key and the additional data it was sealed by,
+// plain values are returned as is.
+func (k *Keyring) Decrypt(value string, additionalData []byte) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if strings.HasPrefix(value, prefixV1) {
+		additionalData = nil
+	}
+
+	parts := strings.Split(trimPrefix(value), ":")
+	if len(parts) != 3 {
+		return "", errors.New("encrypted value is malformed")
+	}
+
+	key, ok := k.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("encryption key %s is not found", parts[0])
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := open(key, wrappedKey, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("data key can't be unwrapped by key %s: %w", parts[0], err)
+	}
+	plain, err := open(dataKey, sealed, additionalData)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+// KeyID returns ID of the key the value is encrypted by, or an empty string for a plain value.
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+
+	return strings.SplitN(trimPrefix(value), ":", 2)[0]
+}
+
+func trimPrefix(value string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(value, prefix), prefixV1)
+}
+
+// ActiveKeyID returns ID of the key new values are encrypted by.
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+func seal(key, plain, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plain, additionalData), nil
+}
+
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,182 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"x-qdo/jiraclick/pkg/config"
+)
+
+var tokenData = AdditionalData("clickup", "general", "token")
+
+func testKey(id string) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte(id[:1]), 32))
+}
+
+func newTestKeyring(t *testing.T, active string, ids ...string) *Keyring {
+	t.Helper()
+
+	cfg := new(config.Config)
+	cfg.Encryption.Active = active
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, testKey(id))
+	}
+	cfg.Encryption.Keys = strings.Join(keys, ",")
+
+	k, err := NewKeyring(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	k := newTestKeyring(t, "a1", "a1")
+
+	sealed, err := k.Encrypt("pk_secret", tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) || !IsCurrent(sealed, "a1") || KeyID(sealed) != "a1" {
+		t.Fatalf("value %q is not sealed by the current format of a1", sealed)
+	}
+	if strings.Contains(sealed, "pk_secret") {
+		t.Fatal("sealed value contains the plain value")
+	}
+
+	plain, err := k.Decrypt(sealed, tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "pk_secret" {
+		t.Errorf("decrypted %q, want %q", plain, "pk_secret")
+	}
+
+	if _, err = k.Decrypt(sealed, AdditionalData("clickup", "random", "token")); err == nil {
+		t.Error("value is decrypted with the additional data of another account")
+	}
+}
+
+func TestKeyringWithoutKeys(t *testing.T) {
+	k := newTestKeyring(t, "")
+
+	value, err := k.Encrypt("pk_secret", tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "pk_secret" {
+		t.Errorf("value is %q, want it as is", value)
+	}
+
+	plain, err := k.Decrypt(value, tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "pk_secret" {
+		t.Errorf("decrypted %q, want %q", plain, "pk_secret")
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	old, err := newTestKeyring(t, "a1", "a1").Encrypt("pk_secret", tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := newTestKeyring(t, "b2", "a1", "b2")
+	if IsCurrent(old, k.ActiveKeyID()) {
+		t.Fatal("value of the previous key is taken for a current one")
+	}
+
+	plain, err := k.Decrypt(old, tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := k.Encrypt(plain, tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyID(rotated) != "b2" {
+		t.Errorf("value is sealed by %q, want b2", KeyID(rotated))
+	}
+
+	if _, err = newTestKeyring(t, "b2", "b2").Decrypt(old, tokenData); err == nil {
+		t.Error("value of a removed key is decrypted")
+	}
+}
+
+func TestKeyringDecryptsV1(t *testing.T) {
+	k := newTestKeyring(t, "a1", "a1")
+
+	dataKey := bytes.Repeat([]byte{7}, 32)
+	wrappedKey, err := seal(k.keys["a1"], dataKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := seal(dataKey, []byte("pk_secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := prefixV1 + "a1:" +
+		base64.StdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.StdEncoding.EncodeToString(sealed)
+
+	if !IsEncrypted(value) || IsCurrent(value, "a1") || KeyID(value) != "a1" {
+		t.Fatalf("v1 value %q isn't recognized", value)
+	}
+
+	plain, err := k.Decrypt(value, tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "pk_secret" {
+		t.Errorf("decrypted %q, want %q", plain, "pk_secret")
+	}
+}
+
+func TestKeyringDecryptErrors(t *testing.T) {
+	k := newTestKeyring(t, "a1", "a1")
+
+	sealed, err := k.Encrypt("pk_secret", tokenData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimPrefix(sealed, prefix), ":")
+
+	tests := map[string]string{
+		"unknown key":         prefix + "c3:" + parts[1] + ":" + parts[2],
+		"missing part":        prefix + "a1:" + parts[1],
+		"extra part":          sealed + ":" + parts[2],
+		"broken data key":     prefix + "a1:***:" + parts[2],
+		"broken value":        prefix + "a1:" + parts[1] + ":***",
+		"short value":         prefix + "a1:" + parts[1] + ":" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"swapped data key":    prefix + "a1:" + parts[2] + ":" + parts[1],
+		"tampered value":      prefix + "a1:" + parts[1] + ":" + tamper(t, parts[2]),
+		"tampered data key":   prefix + "a1:" + tamper(t, parts[1]) + ":" + parts[2],
+		"v2 value made as v1": prefixV1 + strings.TrimPrefix(sealed, prefix),
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			if plain, err := k.Decrypt(value, tokenData); err == nil {
+				t.Errorf("decrypted %q, want an error", plain)
+			}
+		})
+	}
+}
+
+func tamper(t *testing.T, encoded string) string {
+	t.Helper()
+
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-1] ^= 1
+
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,153 @@
+package syncer
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/provider/jira"
+)
+
+// SyncClickUpChanges applies the changes of a ClickUp webhook to the linked Jira issue.
+func (s *Syncer) SyncClickUpChanges(
+	ctx context.Context,
+	link *model.TaskLink,
+	event *clickup.WebhookEvent,
+	task *clickup.Task,
+) error {
+	var (
+		status  *model.StatusMapping
+		update  bool
+		changes []fieldChange
+	)
+
+	ctx, span := otel.Tracer("syncer").Start(ctx, "SyncClickUpChanges")
+	defer span.End()
+
+	settings := s.jira.GetSyncSettings(link.SlackChannel)
+	issue := &jira.Task{ID: link.JiraID}
+
+	for _, change := range clickUpFieldChanges(event, task) {
+		if !settings.ToJira(change.field) {
+			continue
+		}
+		echo, err := s.isEcho(ctx, link, model.SyncOriginClickUp, change)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		} else if echo {
+			span.AddEvent("echo skipped", trace.WithAttributes(attribute.String("field", string(change.field))))
+			continue
+		}
+
+		switch change.field {
+		case model.SyncFieldName:
+			issue.Title = change.value
+		case model.SyncFieldDescription:
+			issue.Description = change.value
+		case model.SyncFieldPriority:
+			issue.Priority = jiraPriorities[change.value]
+		case model.SyncFieldAssignee:
+			issue.Assignee = change.value
+		case model.SyncFieldDueDate:
+			if dueDate, err := time.Parse(jira.JiraDateFormat, change.value); err == nil {
+				issue.DueDate = &dueDate
+			}
+		case model.SyncFieldStatus:
+			mappings, err := s.db.GetStatusMappings(ctx, link.SlackChannel)
+			if err != nil {
+				span.RecordError(err)
+				return err
+			}
+			if status = mappings.ByClickUpStatus(change.value); status == nil {
+				span.AddEvent("status is not mapped", trace.WithAttributes(attribute.String("status", change.value)))
+				if err = s.syncFailed(ctx, link, model.SyncOriginClickUp, change, "ClickUp status is not mapped to a Jira transition"); err != nil {
+					span.RecordError(err)
+					return err
+				}
+				continue
+			}
+			change.value = status.JiraStatus
+		}
+		if change.field != model.SyncFieldStatus {
+			update = true
+		}
+		changes = append(changes, change)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// marks are stored before the write, otherwise the Jira webhook may outrun them
+	if err := s.markSynced(ctx, link, model.SyncOriginJira, changes); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	client, err := s.jira.GetInstance(link.SlackChannel)
+	if err != nil {
+		span.RecordError(err)
+		s.unmarkSynced(ctx, link, model.SyncOriginJira, changes)
+		return err
+	}
+	if update {
+		if err := client.UpdateIssue(ctx, issue); err != nil {
+			span.RecordError(err)
+			s.unmarkSynced(ctx, link, model.SyncOriginJira, changes)
+			return err
+		}
+	}
+	if status != nil {
+		if err := client.TransitionIssue(ctx, link.JiraID, status.JiraTransition, status.JiraStatus); err != nil {
+			span.RecordError(err)
+			// the other fields are written already, so their webhook is still an echo
+			s.unmarkSynced(ctx, link, model.SyncOriginJira, []fieldChange{{model.SyncFieldStatus, status.JiraStatus}})
+			return err
+		}
+	}
+	// the sync state is kept in the sync marks, a write to the task would only trigger one more webhook
+	span.AddEvent("changes synced to Jira")
+
+	return nil
+}
+
+func clickUpFieldChanges(event *clickup.WebhookEvent, task *clickup.Task) []fieldChange {
+	changes := make([]fieldChange, 0, len(event.Changes))
+	for _, historyItem := range event.Changes {
+		_, after, err := historyItem.Decode()
+		if err != nil {
+			continue
+		}
+
+		switch historyItem.Field {
+		case clickup.FieldName:
+			changes = append(changes, fieldChange{model.SyncFieldName, task.Name})
+		case clickup.FieldContent:
+			changes = append(changes, fieldChange{model.SyncFieldDescription, task.Description})
+		case clickup.FieldStatus:
+			if status, ok := after.(*clickup.HistoryStatus); ok {
+				changes = append(changes, fieldChange{model.SyncFieldStatus, status.Status})
+			}
+		case clickup.FieldPriority:
+			if priority, ok := after.(*clickup.HistoryPriority); ok {
+				changes = append(changes, fieldChange{model.SyncFieldPriority, priority.Priority})
+			}
+		case clickup.FieldAssigneeAdd, clickup.FieldAssigneeRem:
+			if len(task.Assignees) > 0 {
+				changes = append(changes, fieldChange{model.SyncFieldAssignee, task.Assignees[0].Email})
+			}
+		case clickup.FieldDueDate:
+			if dueDate, ok := after.(*time.Time); ok {
+				changes = append(changes, fieldChange{model.SyncFieldDueDate, dueDate.Format(jira.JiraDateFormat)})
+			}
+		}
+	}
+
+	return changes
+}
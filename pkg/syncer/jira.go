@@ -0,0 +1,168 @@
+package syncer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/provider/jira"
+)
+
+// SyncJiraChanges applies the changes of a Jira webhook to the linked ClickUp task.
+func (s *Syncer) SyncJiraChanges(ctx context.Context, link *model.TaskLink, event *jira.WebhookEvent) error {
+	var changes []fieldChange
+
+	ctx, span := otel.Tracer("syncer").Start(ctx, "SyncJiraChanges")
+	defer span.End()
+
+	settings := s.jira.GetSyncSettings(link.SlackChannel)
+	client, err := s.clickup.GetInstance(link.SlackChannel)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	request := new(clickup.PutClickUpTaskRequest)
+
+	for _, change := range jiraFieldChanges(event) {
+		if !settings.ToClickUp(change.field) {
+			continue
+		}
+		echo, err := s.isEcho(ctx, link, model.SyncOriginJira, change)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		} else if echo {
+			span.AddEvent("echo skipped", trace.WithAttributes(attribute.String("field", string(change.field))))
+			continue
+		}
+
+		switch change.field {
+		case model.SyncFieldName:
+			request.Name = change.value
+		case model.SyncFieldDescription:
+			request.Description = change.value
+		case model.SyncFieldStatus:
+			mappings, err := s.db.GetStatusMappings(ctx, link.SlackChannel)
+			if err != nil {
+				span.RecordError(err)
+				return err
+			}
+			status := mappings.ByJiraStatus(change.value)
+			if status == nil {
+				span.AddEvent("status is not mapped", trace.WithAttributes(attribute.String("status", change.value)))
+				if err = s.syncFailed(ctx, link, model.SyncOriginJira, change, "Jira status is not mapped to a ClickUp status"); err != nil {
+					span.RecordError(err)
+					return err
+				}
+				continue
+			}
+			request.Status = status.ClickUpStatus
+			change.value = status.ClickUpStatus
+		case model.SyncFieldPriority:
+			priority := clickUpPriorities[change.value]
+			request.Priority = &priority
+		case model.SyncFieldAssignee:
+			assignees, err := s.clickUpAssignees(ctx, client, link.ClickupID, change.value)
+			if err != nil {
+				span.RecordError(err)
+				return err
+			} else if assignees == nil {
+				continue
+			}
+			request.Assignees = assignees
+		case model.SyncFieldDueDate:
+			dueDate, err := time.Parse(jira.JiraDateFormat, change.value)
+			if err != nil {
+				continue
+			}
+			timestamp := dueDate.UnixNano() / 1e6
+			request.DueDate = &timestamp
+		}
+		changes = append(changes, change)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// marks are stored before the write, otherwise the ClickUp webhook may outrun them
+	if err := s.markSynced(ctx, link, model.SyncOriginClickUp, changes); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := client.UpdateTask(ctx, link.ClickupID, request); err != nil {
+		span.RecordError(err)
+		s.unmarkSynced(ctx, link, model.SyncOriginClickUp, changes)
+		return err
+	}
+	span.AddEvent("changes synced to ClickUp")
+
+	return nil
+}
+
+// clickUpAssignees replaces current assignees of the task with the list member having the email.
+func (s *Syncer) clickUpAssignees(
+	ctx context.Context,
+	client clickup.ClientInterface,
+	taskID, email string,
+) (*clickup.AssigneesUpdate, error) {
+	members, err := client.GetListMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	task, err := client.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range members {
+		if strings.EqualFold(member.Email, email) {
+			assignees := &clickup.AssigneesUpdate{Add: []int{member.ID}, Rem: []int{}}
+			for _, assignee := range task.Assignees {
+				if assignee.ID != member.ID {
+					assignees.Rem = append(assignees.Rem, assignee.ID)
+				}
+			}
+			return assignees, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func jiraFieldChanges(event *jira.WebhookEvent) []fieldChange {
+	changes := make([]fieldChange, 0, len(event.Changelog.Items))
+	for _, item := range event.Changelog.Items {
+		switch item.Field {
+		case "summary":
+			changes = append(changes, fieldChange{model.SyncFieldName, item.ToString})
+		case "description":
+			changes = append(changes, fieldChange{model.SyncFieldDescription, item.ToString})
+		case "status":
+			changes = append(changes, fieldChange{model.SyncFieldStatus, item.ToString})
+		case "priority":
+			if priority := priorityFromJira(item.ToString); priority != "" {
+				changes = append(changes, fieldChange{model.SyncFieldPriority, priority})
+			}
+		case "assignee":
+			if assignee, ok := event.Issue.Fields["assignee"].(map[string]interface{}); ok {
+				if email, ok := assignee["emailAddress"].(string); ok && email != "" {
+					changes = append(changes, fieldChange{model.SyncFieldAssignee, email})
+				}
+			}
+		case "duedate":
+			if len(item.To) >= len(jira.JiraDateFormat) {
+				changes = append(changes, fieldChange{model.SyncFieldDueDate, item.To[:len(jira.JiraDateFormat)]})
+			}
+		}
+	}
+
+	return changes
+}
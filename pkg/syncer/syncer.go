@@ -0,0 +1,113 @@
+package syncer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"x-qdo/jiraclick/pkg/contract"
+	"x-qdo/jiraclick/pkg/model"
+	"x-qdo/jiraclick/pkg/provider/clickup"
+	"x-qdo/jiraclick/pkg/provider/jira"
+	"x-qdo/jiraclick/pkg/publisher"
+)
+
+// echoTTL limits how long a written value is expected to come back from the other side's webhook.
+const echoTTL = 10 * time.Minute
+
+type Syncer struct {
+	jira      *jira.ConnectorPool
+	clickup   *clickup.ConnectorPool
+	db        contract.Storage
+	publisher *publisher.EventPublisher
+}
+
+type fieldChange struct {
+	field model.SyncField
+	value string
+}
+
+func NewSyncer(
+	jira *jira.ConnectorPool,
+	clickup *clickup.ConnectorPool,
+	db contract.Storage,
+	p *publisher.EventPublisher,
+) *Syncer {
+	return &Syncer{
+		jira:      jira,
+		clickup:   clickup,
+		db:        db,
+		publisher: p,
+	}
+}
+
+func (s *Syncer) isEcho(ctx context.Context, link *model.TaskLink, origin model.SyncOrigin, change fieldChange) (bool, error) {
+	return s.db.ConsumeSyncMark(ctx, model.NewSyncMark(link, origin, change.field, change.value), echoTTL)
+}
+
+func (s *Syncer) markSynced(ctx context.Context, link *model.TaskLink, target model.SyncOrigin, changes []fieldChange) error {
+	for _, change := range changes {
+		if err := s.db.CreateSyncMark(ctx, model.NewSyncMark(link, target, change.field, change.value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unmarkSynced deletes marks of changes which aren't written, so a later webhook with the same value isn't taken for an echo.
+func (s *Syncer) unmarkSynced(ctx context.Context, link *model.TaskLink, target model.SyncOrigin, changes []fieldChange) {
+	for _, change := range changes {
+		if _, err := s.db.ConsumeSyncMark(ctx, model.NewSyncMark(link, target, change.field, change.value), echoTTL); err != nil {
+			trace.SpanFromContext(ctx).RecordError(err)
+			return
+		}
+	}
+}
+
+func (s *Syncer) syncFailed(
+	ctx context.Context,
+	link *model.TaskLink,
+	origin model.SyncOrigin,
+	change fieldChange,
+	reason string,
+) error {
+	return s.publisher.TaskSyncFailed(ctx, model.TaskSyncFailure{
+		ClickupID: link.ClickupID,
+		JiraID:    link.JiraID,
+		Origin:    origin,
+		Field:     change.field,
+		Value:     change.value,
+		Reason:    reason,
+	}, link.SlackChannel)
+}
+
+// Priorities are passed around by their ClickUp names.
+var jiraPriorities = map[string]string{
+	"urgent": "Highest",
+	"high":   "High",
+	"normal": "Medium",
+	"low":    "Low",
+}
+
+var clickUpPriorities = map[string]int{
+	"urgent": 1,
+	"high":   2,
+	"normal": 3,
+	"low":    4,
+}
+
+func priorityFromJira(name string) string {
+	if strings.EqualFold(name, "Lowest") {
+		return "low"
+	}
+	for clickUpName, jiraName := range jiraPriorities {
+		if strings.EqualFold(name, jiraName) {
+			return clickUpName
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1 @@
+alter table task_links alter column slack_ts drop not null;
@@ -0,0 +1,2 @@
+alter table outbox_events
+    add headers jsonb;
@@ -0,0 +1,6 @@
+alter table task_links
+    add request_id varchar(64);
+
+create unique index task_links_request_index
+    on task_links (slack_channel, request_id)
+    where slack_ts is null;
@@ -0,0 +1,2 @@
+create index outbox_events_sent_at_index
+    on outbox_events (sent_at) where sent_at is not null;
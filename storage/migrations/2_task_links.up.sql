@@ -0,0 +1,21 @@
+create table task_links
+(
+    id serial primary key,
+    slack_channel varchar(10) not null,
+    slack_ts varchar(32) not null,
+    clickup_id varchar(32),
+    jira_id varchar(32),
+    create_at timestamp default now() not null,
+    update_at timestamp
+);
+
+create unique index task_links_slack_thread_index
+    on task_links (slack_channel, slack_ts);
+
+create index task_links_clickup_id_index
+    on task_links (clickup_id);
+
+create index task_links_jira_id_index
+    on task_links (jira_id);
+
+alter table task_links owner to root;
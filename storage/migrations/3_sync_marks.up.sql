@@ -0,0 +1,14 @@
+create table sync_marks
+(
+    id serial primary key,
+    link_id integer not null references task_links (id) on delete cascade,
+    target varchar(16) not null,
+    field varchar(32) not null,
+    value_hash varchar(64) not null,
+    create_at timestamp default now() not null
+);
+
+create index sync_marks_lookup_index
+    on sync_marks (link_id, target, field, value_hash);
+
+alter table sync_marks owner to root;
@@ -0,0 +1,18 @@
+create table status_mappings
+(
+    id serial primary key,
+    slack_channel varchar(10) not null,
+    clickup_status varchar(64) not null,
+    jira_status varchar(64) not null,
+    jira_transition varchar(64),
+    create_at timestamp default now() not null,
+    update_at timestamp
+);
+
+create unique index status_mappings_clickup_status_index
+    on status_mappings (slack_channel, lower(clickup_status));
+
+create index status_mappings_jira_status_index
+    on status_mappings (slack_channel, lower(jira_status));
+
+alter table status_mappings owner to root;
@@ -0,0 +1,12 @@
+create table processed_messages
+(
+    message_id varchar(64) primary key,
+    action varchar(64) not null,
+    result jsonb,
+    create_at timestamp default now() not null
+);
+
+create index processed_messages_create_at_index
+    on processed_messages (create_at);
+
+alter table processed_messages owner to root;
@@ -0,0 +1,14 @@
+create table outbox_events
+(
+    id serial primary key,
+    exchange varchar(64) not null,
+    routing_key varchar(128) not null,
+    payload jsonb not null,
+    create_at timestamp default now() not null,
+    sent_at timestamp
+);
+
+create index outbox_events_pending_index
+    on outbox_events (id) where sent_at is null;
+
+alter table outbox_events owner to root;
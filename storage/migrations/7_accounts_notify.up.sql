@@ -0,0 +1,15 @@
+create or replace function notify_accounts_changed() returns trigger as
+$$
+begin
+    perform pg_notify('accounts_changed', '');
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger accounts_changed_trigger
+    after insert or update or delete or truncate
+    on accounts
+    for each statement
+execute procedure notify_accounts_changed();
+
+alter function notify_accounts_changed() owner to root;
@@ -0,0 +1,22 @@
+create table webhook_history_items
+(
+    id varchar(64) primary key,
+    task_id varchar(64) not null,
+    create_at timestamp default now() not null
+);
+
+create index webhook_history_items_create_at_index
+    on webhook_history_items (create_at);
+
+alter table webhook_history_items owner to root;
+
+create table task_field_changes
+(
+    task_id varchar(64) not null,
+    field varchar(64) not null,
+    history_item_id varchar(64) not null,
+    changed_at timestamp not null,
+    primary key (task_id, field)
+);
+
+alter table task_field_changes owner to root;